@@ -0,0 +1,368 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// backupVersion is written to every backup; restore refuses files from a newer version.
+const backupVersion = 1
+
+// backup is the JSON document written by gtd backup: every task, audit event, context
+// setting and inbox item, with the database's own IDs so carried_from_id lineage can be rebuilt. Values are
+// copied from the columns as stored, including timestamps, so a restore is exact.
+type backup struct {
+	Version  int             `json:"version"`
+	Created  string          `json:"created"`
+	Tasks    []backupTask    `json:"tasks"`
+	Events   []backupEvent   `json:"events"`
+	Contexts []backupContext `json:"contexts"`
+	Inbox    []backupInbox   `json:"inbox,omitempty"` // missing from backups older than the inbox
+}
+
+type backupTask struct {
+	ID            int64  `json:"id"`
+	UID           string `json:"uid"`
+	Date          string `json:"date"`
+	Context       string `json:"context"`
+	Description   string `json:"description"`
+	Priority      string `json:"priority"`
+	TimeEstimate  string `json:"time_estimate,omitempty"`
+	Status        int    `json:"status"`
+	CarriedFromID *int64 `json:"carried_from_id,omitempty"`
+	DueDate       string `json:"due_date,omitempty"`
+	Notes         string `json:"notes,omitempty"`
+	Pomodoros     int    `json:"pomodoros,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+	CompletedAt   string `json:"completed_at,omitempty"`
+}
+
+type backupEvent struct {
+	ID        int64  `json:"id"`
+	TaskID    int64  `json:"task_id"`
+	Event     string `json:"event"`
+	Detail    string `json:"detail,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type backupContext struct {
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+}
+
+type backupInbox struct {
+	ID        int64  `json:"id"`
+	Context   string `json:"context"`
+	Text      string `json:"text"`
+	Backlog   bool   `json:"backlog,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Backup reads the whole database into a backup, in ID order.
+func (s *Store) Backup() (backup, error) {
+	b := backup{Version: backupVersion, Created: s.timestamp(), Tasks: []backupTask{}, Events: []backupEvent{}, Contexts: []backupContext{}}
+
+	rows, err := s.db.Query(`
+		SELECT id, uid, date, context, description, priority, time_estimate, status, carried_from_id,
+			due_date, notes, pomodoros, created_at, updated_at, completed_at
+		FROM tasks ORDER BY id`)
+	if err != nil {
+		return backup{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t backupTask
+		var carriedFromID sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.UID, &t.Date, &t.Context, &t.Description, &t.Priority, &t.TimeEstimate, &t.Status, &carriedFromID,
+			&t.DueDate, &t.Notes, &t.Pomodoros, &t.CreatedAt, &t.UpdatedAt, &t.CompletedAt); err != nil {
+			return backup{}, err
+		}
+		if carriedFromID.Valid {
+			t.CarriedFromID = &carriedFromID.Int64
+		}
+		b.Tasks = append(b.Tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return backup{}, err
+	}
+
+	events, err := s.db.Query(`SELECT id, task_id, event, detail, created_at FROM task_events ORDER BY id`)
+	if err != nil {
+		return backup{}, err
+	}
+	defer events.Close()
+	for events.Next() {
+		var e backupEvent
+		if err := events.Scan(&e.ID, &e.TaskID, &e.Event, &e.Detail, &e.CreatedAt); err != nil {
+			return backup{}, err
+		}
+		b.Events = append(b.Events, e)
+	}
+	if err := events.Err(); err != nil {
+		return backup{}, err
+	}
+
+	contexts, err := s.db.Query(`SELECT name, archived FROM contexts ORDER BY name`)
+	if err != nil {
+		return backup{}, err
+	}
+	defer contexts.Close()
+	for contexts.Next() {
+		var c backupContext
+		if err := contexts.Scan(&c.Name, &c.Archived); err != nil {
+			return backup{}, err
+		}
+		b.Contexts = append(b.Contexts, c)
+	}
+	if err := contexts.Err(); err != nil {
+		return backup{}, err
+	}
+
+	inbox, err := s.db.Query(`SELECT id, context, text, backlog, created_at FROM inbox ORDER BY id`)
+	if err != nil {
+		return backup{}, err
+	}
+	defer inbox.Close()
+	for inbox.Next() {
+		var item backupInbox
+		if err := inbox.Scan(&item.ID, &item.Context, &item.Text, &item.Backlog, &item.CreatedAt); err != nil {
+			return backup{}, err
+		}
+		b.Inbox = append(b.Inbox, item)
+	}
+	return b, inbox.Err()
+}
+
+// Restore loads a backup. With replace, the database is emptied first and every row comes
+// back with its original ID. Otherwise the backup is merged in: tasks whose UID is already in
+// the database are skipped, the rest get new IDs, and carried_from_id and the audit log are
+// remapped to match, and inbox items already there (same context, text and capture time) are
+// skipped. Events belonging to tasks that had already been deleted when the backup
+// was taken are only restored by replace, as there is no task left to attach them to.
+func (s *Store) Restore(b backup, replace bool) (added, skipped int, err error) {
+	if b.Version < 1 || b.Version > backupVersion {
+		return 0, 0, fmt.Errorf("unsupported backup version %d (this gtd reads version %d)", b.Version, backupVersion)
+	}
+	if err := s.snapshotBefore("restore"); err != nil {
+		return 0, 0, err
+	}
+	tasks := append([]backupTask(nil), b.Tasks...)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	err = s.inTx(func(tx *sql.Tx) error {
+		if replace {
+			if _, err := tx.Exec(`DELETE FROM task_events; DELETE FROM tasks; DELETE FROM contexts; DELETE FROM inbox`); err != nil {
+				return err
+			}
+			for _, t := range tasks {
+				if _, err := insertBackupTask(tx, t, true); err != nil {
+					return fmt.Errorf("task %d: %w", t.ID, err)
+				}
+			}
+			for _, e := range b.Events {
+				if _, err := tx.Exec(`INSERT INTO task_events (id, task_id, event, detail, created_at) VALUES (?, ?, ?, ?, ?)`,
+					e.ID, e.TaskID, e.Event, e.Detail, e.CreatedAt); err != nil {
+					return fmt.Errorf("event %d: %w", e.ID, err)
+				}
+			}
+			for _, c := range b.Contexts {
+				if _, err := tx.Exec(`INSERT INTO contexts (name, archived) VALUES (?, ?)`, c.Name, c.Archived); err != nil {
+					return err
+				}
+			}
+			for _, item := range b.Inbox {
+				if _, err := tx.Exec(`INSERT INTO inbox (id, context, text, backlog, created_at) VALUES (?, ?, ?, ?, ?)`,
+					item.ID, item.Context, item.Text, item.Backlog, item.CreatedAt); err != nil {
+					return fmt.Errorf("inbox item %d: %w", item.ID, err)
+				}
+			}
+			added = len(tasks)
+			return nil
+		}
+
+		ids := map[int64]int64{}     // backup ID → database ID, for every task in the backup
+		inserted := map[int64]bool{} // backup IDs that were added by this restore
+		for _, t := range tasks {
+			var existing int64
+			err := tx.QueryRow(`SELECT id FROM tasks WHERE uid = ? AND uid != ''`, t.UID).Scan(&existing)
+			if err == nil {
+				ids[t.ID] = existing
+				skipped++
+				continue
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+
+			id, err := insertBackupTask(tx, t, false)
+			if err != nil {
+				return fmt.Errorf("task %d: %w", t.ID, err)
+			}
+			ids[t.ID] = id
+			inserted[t.ID] = true
+			added++
+		}
+
+		// Lineage can point at any task in the backup, including ones inserted after it
+		for _, t := range tasks {
+			if !inserted[t.ID] || t.CarriedFromID == nil {
+				continue
+			}
+			if from, ok := ids[*t.CarriedFromID]; ok {
+				if _, err := tx.Exec(`UPDATE tasks SET carried_from_id = ? WHERE id = ?`, from, ids[t.ID]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, e := range b.Events {
+			if !inserted[e.TaskID] {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO task_events (task_id, event, detail, created_at) VALUES (?, ?, ?, ?)`,
+				ids[e.TaskID], e.Event, e.Detail, e.CreatedAt); err != nil {
+				return err
+			}
+		}
+		for _, c := range b.Contexts {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO contexts (name, archived) VALUES (?, ?)`, c.Name, c.Archived); err != nil {
+				return err
+			}
+		}
+		for _, item := range b.Inbox {
+			_, err := tx.Exec(`
+				INSERT INTO inbox (context, text, backlog, created_at)
+				SELECT ?, ?, ?, ?
+				WHERE NOT EXISTS (SELECT 1 FROM inbox WHERE context = ? AND text = ? AND created_at = ?)`,
+				item.Context, item.Text, item.Backlog, item.CreatedAt, item.Context, item.Text, item.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return added, skipped, nil
+}
+
+// insertBackupTask writes a task row exactly as it was backed up, except that a missing UID is
+// generated. Without keepID the row gets a new ID and no lineage, which the caller fills in
+// once every ID is known.
+func insertBackupTask(tx *sql.Tx, t backupTask, keepID bool) (int64, error) {
+	var id, carriedFromID any
+	if keepID {
+		id = t.ID
+		if t.CarriedFromID != nil {
+			carriedFromID = *t.CarriedFromID
+		}
+	}
+	if t.UID == "" {
+		t.UID = uuid.NewString()
+	}
+	res, err := tx.Exec(`
+		INSERT INTO tasks (id, uid, date, context, description, priority, time_estimate, status, carried_from_id,
+			due_date, notes, pomodoros, created_at, updated_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, t.UID, t.Date, t.Context, t.Description, t.Priority, t.TimeEstimate, t.Status, carriedFromID,
+		t.DueDate, t.Notes, t.Pomodoros, t.CreatedAt, t.UpdatedAt, t.CompletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// runBackup implements "gtd backup", writing the JSON backup to stdout, and the snapshot
+// commands "gtd backup list" and "gtd backup restore".
+func runBackup(store *Store, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			if len(args) > 1 {
+				return fmt.Errorf("unknown argument %q\n%s", args[1], commandUsage("backup"))
+			}
+			return runSnapshotList(store)
+		case "restore":
+			return runSnapshotRestore(store, args[1:])
+		}
+		return fmt.Errorf("unknown argument %q\n%s", args[0], commandUsage("backup"))
+	}
+	b, err := store.Backup()
+	if err != nil {
+		return err
+	}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(b)
+}
+
+// runRestore implements "gtd restore <file> [--replace --yes]"; "-" reads standard input.
+func runRestore(store *Store, args []string) error {
+	usage := commandUsage("restore")
+
+	var path string
+	replace, yes := false, false
+	for _, arg := range args {
+		switch {
+		case arg == "--merge":
+			replace = false
+		case arg == "--replace":
+			replace = true
+		case arg == "--yes":
+			yes = true
+		case path == "" && (arg == "-" || !strings.HasPrefix(arg, "-")):
+			path = arg
+		default:
+			return fmt.Errorf("unknown argument %q\n%s", arg, usage)
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("%s", usage)
+	}
+
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	var b backup
+	if err := json.NewDecoder(in).Decode(&b); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if replace && !yes {
+		var count int
+		if err := store.db.QueryRow(`SELECT COUNT(*) FROM tasks`).Scan(&count); err != nil {
+			return err
+		}
+		return fmt.Errorf("this would replace all %d task(s) in %s; re-run with --yes to confirm", count, store.path)
+	}
+
+	added, skipped, err := store.Restore(b, replace)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if replace {
+		fmt.Printf("Restored %d task(s) from %s.\n", added, filepath.Base(path))
+		return nil
+	}
+	msg := fmt.Sprintf("Merged %d task(s) from %s", added, filepath.Base(path))
+	if skipped > 0 {
+		msg += fmt.Sprintf(", skipped %d already present", skipped)
+	}
+	fmt.Println(msg + ".")
+	return nil
+}
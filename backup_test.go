@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// backupFixture builds a store with a carried task, notes, history, a deleted task and an
+// archived context.
+func backupFixture(t *testing.T) *Store {
+	t.Helper()
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Patch web servers", PriorityA, "2h", "work")
+	s.AddTask("2025-06-01", "Gone", PriorityC, "", "work")
+	s.AddTask("2025-06-01", "Fix the fence", PriorityB, "1d", "home")
+
+	tasks, _ := s.GetTasksForDate("2025-06-01", "work")
+	s.UpdateTask(tasks[0].ID, "Patch web servers", PriorityA, "2h", "2025-06-03", "Start with web01")
+	s.MarkInProgress(tasks[0].ID)
+	s.DeleteTask(tasks[1].ID)
+	patch, _ := s.GetTask(tasks[0].ID)
+	s.CarryOverTasks([]Task{patch}, "2025-06-02", "work")
+	s.SetContextArchived("home", true)
+	return s
+}
+
+func TestBackupRestoreReplace(t *testing.T) {
+	src := backupFixture(t)
+	b, err := src.Backup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != backupVersion || len(b.Tasks) != 3 || len(b.Contexts) != 1 {
+		t.Fatalf("unexpected backup: %+v", b)
+	}
+
+	dst := newTestStore(t)
+	dst.AddTask("2025-01-01", "Replaced", PriorityB, "", "default")
+	added, _, err := dst.Restore(b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 {
+		t.Errorf("added = %d, want 3", added)
+	}
+
+	again, err := dst.Backup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again.Created = b.Created
+	if !reflect.DeepEqual(again, b) {
+		t.Errorf("replace isn't exact:\ngot  %+v\nwant %+v", again, b)
+	}
+
+	// The search index follows the restored rows
+	if results, _ := dst.SearchTasks("web01", ""); len(results) != 1 {
+		t.Errorf("expected the restored notes to be searchable, got %+v", results)
+	}
+	if results, _ := dst.SearchTasks("replaced", ""); len(results) != 0 {
+		t.Errorf("expected the replaced task to be gone from the index, got %+v", results)
+	}
+}
+
+func TestBackupRestoreMerge(t *testing.T) {
+	src := backupFixture(t)
+	b, _ := src.Backup()
+
+	dst := newTestStore(t)
+	dst.AddTask("2025-05-30", "Already here", PriorityB, "", "work")
+	dst.AddTask("2025-05-30", "Also here", PriorityB, "", "work")
+	added, skipped, err := dst.Restore(b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 || skipped != 0 {
+		t.Errorf("added, skipped = %d, %d; want 3, 0", added, skipped)
+	}
+
+	carried, _ := dst.GetTasksForDate("2025-06-02", "work")
+	if len(carried) != 1 || carried[0].CarriedFromID == nil {
+		t.Fatalf("expected the carried copy with its lineage, got %+v", carried)
+	}
+	original, err := dst.GetTask(*carried[0].CarriedFromID)
+	if err != nil || original.Date != "2025-06-01" || original.Description != "Patch web servers" || original.Notes != "Start with web01" {
+		t.Errorf("carried_from_id should point at the remapped original, got %+v (%v)", original, err)
+	}
+
+	// The history follows the remapped IDs; the deleted task's events have nothing to attach to
+	srcCarried, _ := src.GetTasksForDate("2025-06-02", "work")
+	want, _ := src.GetTaskEvents(srcCarried[0].ID)
+	got, _ := dst.GetTaskEvents(carried[0].ID)
+	if len(got) != len(want) || len(got) < 4 {
+		t.Fatalf("expected %d events, got %+v", len(want), got)
+	}
+	for i := range got {
+		if got[i].Event != want[i].Event || got[i].Detail != want[i].Detail || !got[i].At.Equal(want[i].At) {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	contexts, _ := dst.ListContexts()
+	for _, c := range contexts {
+		if c.Archived != (c.Name == "home") {
+			t.Errorf("context %s: archived = %v", c.Name, c.Archived)
+		}
+	}
+
+	// Merging the same backup again adds nothing
+	added, skipped, err = dst.Restore(b, false)
+	if err != nil || added != 0 || skipped != 3 {
+		t.Errorf("second merge: added, skipped = %d, %d (%v); want 0, 3", added, skipped, err)
+	}
+}
+
+func TestRestoreRejectsUnknownVersion(t *testing.T) {
+	s := newTestStore(t)
+	for _, v := range []int{0, backupVersion + 1} {
+		if _, _, err := s.Restore(backup{Version: v}, false); err == nil {
+			t.Errorf("version %d: expected an error", v)
+		}
+	}
+}
+
+func TestBackupRestoreCommands(t *testing.T) {
+	src := backupFixture(t)
+	output := captureOutput(t, func() error { return runBackup(src, nil) })
+
+	var b backup
+	if err := json.Unmarshal([]byte(output), &b); err != nil {
+		t.Fatalf("backup isn't JSON: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, `"carried_from_id": `) || !strings.Contains(output, `"version": 1`) {
+		t.Errorf("unexpected backup:\n%s", output)
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.json")
+	if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTestStore(t)
+	msg := captureOutput(t, func() error { return runRestore(dst, []string{path}) })
+	if msg != "Merged 3 task(s) from backup.json.\n" {
+		t.Errorf("unexpected merge message: %q", msg)
+	}
+	msg = captureOutput(t, func() error { return runRestore(dst, []string{path, "--merge"}) })
+	if !strings.Contains(msg, "Merged 0 task(s)") || !strings.Contains(msg, "skipped 3 already present") {
+		t.Errorf("unexpected second merge message: %q", msg)
+	}
+
+	if err := runRestore(dst, []string{path, "--replace"}); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("replace without --yes should ask for confirmation, got %v", err)
+	}
+	msg = captureOutput(t, func() error { return runRestore(dst, []string{"--replace", path, "--yes"}) })
+	if msg != "Restored 3 task(s) from backup.json.\n" {
+		t.Errorf("unexpected replace message: %q", msg)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte("{not json"), 0o644)
+	for _, args := range [][]string{nil, {"--bogus"}, {path, path}, {"missing.json"}, {bad}, {""}} {
+		if err := runRestore(dst, args); err == nil {
+			t.Errorf("runRestore(%v): expected an error", args)
+		}
+	}
+	if err := runBackup(dst, []string{"--bogus"}); err == nil {
+		t.Error("runBackup: expected an error for an unknown argument")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// daySummary is one day's tasks as the calendar shows them.
+type daySummary struct {
+	completionCount     // cancelled tasks excluded, as in stats
+	Open            int // open tasks left behind: not finished and not carried to a later day
+}
+
+// GetDaySummaries counts each day's tasks between from and to inclusive (yyyy-mm-dd), keyed by
+// date. Days without tasks are left out. An empty context counts every context.
+func (s *Store) GetDaySummaries(from, to, context string) (map[string]daySummary, error) {
+	where := "date BETWEEN ? AND ?"
+	args := []any{StatusCancelled, StatusDone, from, to}
+	if context != "" {
+		where += " AND context = ?"
+		args = append(args, context)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT date,
+		       SUM(status != ?),
+		       SUM(status = ?),
+		       SUM(`+openStatus+` AND id NOT IN (SELECT carried_from_id FROM tasks WHERE carried_from_id IS NOT NULL))
+		FROM tasks
+		WHERE `+where+`
+		GROUP BY date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := map[string]daySummary{}
+	for rows.Next() {
+		var date string
+		var d daySummary
+		if err := rows.Scan(&date, &d.Total, &d.Done, &d.Open); err != nil {
+			return nil, err
+		}
+		days[date] = d
+	}
+	return days, rows.Err()
+}
+
+// addMonths moves t by n months, keeping the day of the month where it exists and otherwise
+// using the month's last day (31 March - 1 month is 28 or 29 February, not 3 March).
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// --- Calendar mode ---
+
+const calendarCellWidth = 7
+
+func (m *model) enterCalendarMode() (tea.Model, tea.Cmd) {
+	m.calendarDate = m.date
+	m.loadCalendar()
+	m.mode = modeCalendar
+	return m, nil
+}
+
+// loadCalendar fetches the day summaries for the month containing calendarDate.
+func (m *model) loadCalendar() {
+	t, _ := time.Parse("2006-01-02", m.calendarDate)
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	context := m.context
+	if m.allContexts {
+		context = ""
+	}
+
+	days, err := m.store.GetDaySummaries(first.Format("2006-01-02"), first.AddDate(0, 1, -1).Format("2006-01-02"), context)
+	if err != nil {
+		m.status = "Error loading calendar."
+		days = nil
+	}
+	m.calendarDays = days
+}
+
+func (m *model) updateCalendar(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	t, _ := time.Parse("2006-01-02", m.calendarDate)
+	// Moving a day at a time has no action of its own, so it stays on the arrows (and h/l)
+	switch k := keyMsg.String(); {
+	case k == "esc", key.Matches(keyMsg, m.keys.Quit, m.keys.ViewDate):
+		m.mode = modeTable
+		return m, nil
+	case k == "enter":
+		m.date = m.calendarDate
+		m.status = ""
+		m.mode = modeTable
+		m.refreshTasks()
+		return m, nil
+	case key.Matches(keyMsg, m.keys.Up):
+		t = t.AddDate(0, 0, -7)
+	case key.Matches(keyMsg, m.keys.Down):
+		t = t.AddDate(0, 0, 7)
+	case k == "[", key.Matches(keyMsg, m.keys.PageUp):
+		t = addMonths(t, -1)
+	case k == "]", key.Matches(keyMsg, m.keys.PageDown):
+		t = addMonths(t, 1)
+	case k == "t", key.Matches(keyMsg, m.keys.Top):
+		t = time.Now()
+	case k == "left", k == "h":
+		t = t.AddDate(0, 0, -1)
+	case k == "right", k == "l":
+		t = t.AddDate(0, 0, 1)
+	default:
+		return m, nil
+	}
+
+	previous := m.calendarDate
+	m.calendarDate = t.Format("2006-01-02")
+	if m.calendarDate[:7] != previous[:7] {
+		m.loadCalendar()
+	}
+	return m, nil
+}
+
+// heatColor is a day's colour in the calendar: all done, some done or none done. ok is false
+// for days with nothing to complete.
+func heatColor(d daySummary) (c lipgloss.TerminalColor, ok bool) {
+	switch {
+	case d.Total == 0:
+		return nil, false
+	case d.Done == d.Total:
+		return theme.Success, true
+	case d.Done > 0:
+		return theme.PriorityB, true
+	}
+	return theme.PriorityA, true
+}
+
+func (m *model) calendarView() string {
+	var s strings.Builder
+
+	t, _ := time.Parse("2006-01-02", m.calendarDate)
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	s.WriteString(statusStyle.Render("  " + first.Format("January 2006")))
+	s.WriteString("\n\n")
+
+	cell := lipgloss.NewStyle().Width(calendarCellWidth)
+	weekStart := config.weekStart()
+	s.WriteString("  ")
+	for i := range 7 {
+		day := time.Weekday((int(weekStart) + i) % 7)
+		s.WriteString(cell.Inherit(helpStyle).Render(" " + day.String()[:2]))
+	}
+	s.WriteString("\n")
+
+	offset := (int(first.Weekday()) - int(weekStart) + 7) % 7
+	s.WriteString("  " + strings.Repeat(" ", offset*calendarCellWidth))
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		summary := m.calendarDays[date]
+
+		content := fmt.Sprintf(" %2d", d.Day())
+		if summary.Open > 0 {
+			content += fmt.Sprintf(" ·%d", summary.Open)
+		}
+
+		style := cell
+		if c, ok := heatColor(summary); ok {
+			style = style.Background(c).Foreground(theme.Selected)
+		}
+		if date == m.calendarDate {
+			style = theme.selectedStyle(cell)
+		}
+		s.WriteString(style.Render(content))
+
+		if (offset+d.Day())%7 == 0 && d.AddDate(0, 0, 1).Month() == first.Month() {
+			s.WriteString("\n  ")
+		}
+	}
+	s.WriteString("\n\n")
+
+	summary := m.calendarDays[m.calendarDate]
+	line := fmt.Sprintf("  %s: ", t.Format("Monday 2 January"))
+	if summary.Total == 0 {
+		line += "no tasks"
+	} else {
+		line += fmt.Sprintf("%d of %d done, %d left open", summary.Done, summary.Total, summary.Open)
+	}
+	s.WriteString(infoStyle.Render(line))
+	s.WriteString("\n\n")
+
+	swatch := func(c lipgloss.TerminalColor) string {
+		return lipgloss.NewStyle().Background(c).Render("  ")
+	}
+	s.WriteString(helpStyle.Render("  ") + swatch(theme.Success) + helpStyle.Render(" all done  ") +
+		swatch(theme.PriorityB) + helpStyle.Render(" some done  ") +
+		swatch(theme.PriorityA) + helpStyle.Render(" none done  ·n tasks left open"))
+	s.WriteString("\n\n")
+	s.WriteString(helpStyle.Render("  ←/→/↑/↓ move · pgup/pgdn month · t today · enter open day · esc back"))
+	s.WriteString("\n")
+	return s.String()
+}
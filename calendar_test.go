@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGetDaySummaries(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-03-03", "Done", PriorityA, "1h", "default")
+	s.AddTask("2025-03-03", "Open", PriorityB, "1h", "default")
+	s.AddTask("2025-03-03", "Cancelled", PriorityC, "1h", "default")
+	s.AddTask("2025-03-03", "Carried", PriorityB, "1h", "default")
+	s.AddTask("2025-03-03", "Other context", PriorityA, "1h", "home")
+	s.AddTask("2025-04-01", "Next month", PriorityA, "1h", "default")
+
+	tasks, _ := s.GetTasksForDate("2025-03-03", "default")
+	for _, task := range tasks {
+		switch task.Description {
+		case "Done":
+			s.MarkComplete(task.ID)
+		case "Cancelled":
+			s.MarkCancelled(task.ID)
+		case "Carried":
+			s.CarryOverTasks([]Task{task}, "2025-03-04", "default")
+		}
+	}
+
+	days, err := s.GetDaySummaries("2025-03-01", "2025-03-31", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("expected 2 days in March, got %v", days)
+	}
+	if d := days["2025-03-03"]; d.Total != 3 || d.Done != 1 || d.Open != 1 {
+		t.Errorf("2025-03-03 = %+v, want 3 counted, 1 done, 1 left open", d)
+	}
+	if d := days["2025-03-04"]; d.Total != 1 || d.Open != 1 {
+		t.Errorf("2025-03-04 = %+v, want the carried copy", d)
+	}
+
+	all, err := s.GetDaySummaries("2025-03-01", "2025-03-31", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := all["2025-03-03"]; d.Total != 4 || d.Open != 2 {
+		t.Errorf("all contexts 2025-03-03 = %+v, want 4 counted, 2 left open", d)
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from string
+		n    int
+		want string
+	}{
+		{"2025-03-15", 1, "2025-04-15"},
+		{"2025-03-31", -1, "2025-02-28"},
+		{"2024-03-31", -1, "2024-02-29"},
+		{"2025-01-31", 1, "2025-02-28"},
+		{"2025-12-10", 1, "2026-01-10"},
+		{"2025-01-10", -1, "2024-12-10"},
+	}
+	for _, tt := range tests {
+		from, _ := time.Parse("2006-01-02", tt.from)
+		if got := addMonths(from, tt.n).Format("2006-01-02"); got != tt.want {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarModeOpensDay(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-03-05", "Forgotten task", PriorityA, "1h", "default")
+	s.AddTask("2025-04-09", "Next month's task", PriorityA, "1h", "default")
+
+	m := newModel(s, "2025-03-04", "default", false)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if m.mode != modeCalendar || m.calendarDate != "2025-03-04" {
+		t.Fatalf("expected v to open the calendar on the current day, mode %v date %s", m.mode, m.calendarDate)
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "March 2025") || !strings.Contains(view, " 5 ·1") {
+		t.Errorf("expected the month with an open-task count on the 5th, got:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if m.calendarDate != "2025-03-05" {
+		t.Errorf("right: calendarDate = %s, want 2025-03-05", m.calendarDate)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if m.calendarDate != "2025-04-12" {
+		t.Errorf("pgdown, down: calendarDate = %s, want 2025-04-12", m.calendarDate)
+	}
+	if d := m.calendarDays["2025-04-09"]; d.Open != 1 {
+		t.Errorf("expected April to be loaded after changing month, got %+v", m.calendarDays)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != modeTable || m.date != "2025-04-09" {
+		t.Fatalf("expected enter to open 2025-04-09, got mode %v date %s", m.mode, m.date)
+	}
+	if len(m.tasks) != 1 || m.tasks[0].Description != "Next month's task" {
+		t.Errorf("expected the day's tasks to load, got %+v", m.tasks)
+	}
+}
+
+func TestCalendarKeysFollowKeymap(t *testing.T) {
+	s := newTestStore(t)
+	cfg := defaultConfig()
+	cfg.Keymap = "emacs"
+	cfg.Keys = map[string][]string{"view_date": {"V"}}
+	useConfig(t, cfg)
+
+	m := newModel(s, "2025-03-04", "default", false)
+	press := func(msg tea.KeyMsg) { m.Update(msg) }
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("V")})
+	if m.mode != modeCalendar {
+		t.Fatalf("expected V to open the calendar, mode %v", m.mode)
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlN})
+	press(tea.KeyMsg{Type: tea.KeyCtrlV})
+	if m.calendarDate != "2025-04-11" {
+		t.Errorf("ctrl+n, ctrl+v: calendarDate = %s, want 2025-04-11", m.calendarDate)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if m.mode != modeCalendar {
+		t.Error("v is no longer bound, so it shouldn't close the calendar")
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlX})
+	if m.mode != modeTable {
+		t.Errorf("expected ctrl+x to close the calendar, mode %v", m.mode)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The command tree describes every gtd command and flag once. It routes subcommands and is the
+// source for --help, the usage lines in error messages, the man page and shell completion. Each
+// command still parses its own arguments; the tree only has to say what they are.
+
+// argKind says what a flag value or positional argument is, for placeholders and completion.
+type argKind int
+
+const (
+	argText     argKind = iota // free text, nothing to complete
+	argDate                    // a date in date_format
+	argContext                 // an existing context
+	argTaskID                  // one of today's tasks
+	argFile                    // a file name
+	argDir                     // a directory name
+	argFormat                  // an export format
+	argShell                   // a shell for gtd completion
+	argCommand                 // a gtd command, for gtd help
+	argSnapshot                // a snapshot in the backups folder
+)
+
+type flagDef struct {
+	name     string  // "--context"
+	value    string  // placeholder for the value; "" with argText for a switch
+	kind     argKind // what the value is; placeholder comes from it for dates, formats and shells
+	help     string
+	required bool
+}
+
+// placeholder is how the flag's value is shown in usage lines.
+func (f flagDef) placeholder() string {
+	switch f.kind {
+	case argDate:
+		return config.DateFormat
+	case argFormat:
+		return strings.Join(exportFormatNames(), "|")
+	case argShell:
+		return strings.Join(completionShells, "|")
+	}
+	return f.value
+}
+
+// takesValue reports whether the flag needs a value, rather than being a switch.
+func (f flagDef) takesValue() bool {
+	return f.value != "" || f.kind != argText
+}
+
+func (f flagDef) String() string {
+	if !f.takesValue() {
+		return f.name
+	}
+	return f.name + " " + f.placeholder()
+}
+
+type command struct {
+	name    string
+	args    string  // positional arguments for usage lines, e.g. "<term>"
+	argKind argKind // what the positional arguments complete to
+	summary string
+	flags   []flagDef
+	subs    []*command
+	run     func(store *Store, args []string) error // nil for subcommands run by their parent
+	noStore bool                                    // runs without opening the database
+	needSub bool                                    // does nothing without a subcommand
+	hidden  bool                                    // left out of help and completion
+
+	path string // full name such as "gtd backup restore", set by linkCommands
+}
+
+var (
+	contextFlag     = flagDef{name: "--context", value: "<name>", kind: argContext, help: "Context to use (default: the default_context setting)"}
+	allContextsFlag = flagDef{name: "--all-contexts", help: "Include every context"}
+	fromFlag        = flagDef{name: "--from", kind: argDate, help: "First day to include"}
+	toFlag          = flagDef{name: "--to", kind: argDate, help: "Last day to include"}
+	yesFlag         = flagDef{name: "--yes", help: "Confirm, since this can't be undone", required: true}
+	formatFlag      = flagDef{name: "--format", kind: argFormat, help: "File format"}
+)
+
+// globalFlags work with every command.
+var globalFlags = []flagDef{
+	{name: "--config", value: "<file>", kind: argFile, help: "Read settings from this file instead of config.toml"},
+	{name: "--db", value: "<file>", kind: argFile, help: "Use this database (or set GTD_DB)"},
+	{name: "--help", help: "Show help"},
+}
+
+// rootCommand is gtd itself, which opens the TUI (or prints with --print); its subs are the
+// subcommands.
+var rootCommand = &command{
+	name:    "gtd",
+	args:    "[date]",
+	argKind: argDate,
+	summary: "Daily task manager for sysadmins",
+	flags: []flagDef{
+		{name: "--print", help: "Print the day's tasks as plain text instead of opening the TUI"},
+		contextFlag,
+		{name: "--all-contexts", help: "Show every context together, with a Context column"},
+		{name: "--filter", value: "<query>", help: "Show only tasks matching a filter query, e.g. \"p:A status:wip\""},
+		{name: "--due-within", value: "<3d|2w>", help: "With --print, list open tasks due within this many days or weeks"},
+	},
+}
+
+func init() {
+	rootCommand.subs = []*command{
+		{name: "stats", run: runStats, summary: "Report completion rates, carry-overs, estimate accuracy and streaks",
+			flags: []flagDef{contextFlag, {name: "--since", kind: argDate, help: "First day to include (default: 30 days ago)"}}},
+		{name: "contexts", run: runContexts, summary: "List contexts with their task counts",
+			flags: []flagDef{{name: "--archived", help: "Include archived contexts"}}},
+		{name: "context", run: runContext, needSub: true, summary: "Rename, merge, archive or delete a context", subs: []*command{
+			{name: "rename", args: "<old> <new>", argKind: argContext, summary: "Rename a context"},
+			{name: "merge", args: "<from> <into>", argKind: argContext, summary: "Move every task in one context into another"},
+			{name: "archive", args: "<name>", argKind: argContext, summary: "Hide a context from the switcher and gtd contexts"},
+			{name: "unarchive", args: "<name>", argKind: argContext, summary: "Bring back an archived context"},
+			{name: "delete", args: "<name>", argKind: argContext, summary: "Delete a context and all its tasks", flags: []flagDef{yesFlag}},
+		}},
+		{name: "config", run: runConfig, needSub: true, noStore: true, summary: "Show the settings in effect", subs: []*command{
+			{name: "show", summary: "Print the effective settings and where they came from"},
+		}},
+		{name: "search", run: runSearch, args: "<term>", summary: "Search task names and notes across every date",
+			flags: []flagDef{contextFlag, allContextsFlag}},
+		{name: "export", run: runExport, summary: "Write tasks to stdout as iCalendar or todo.txt",
+			flags: []flagDef{{name: "--format", kind: argFormat, help: "File format", required: true}, contextFlag, allContextsFlag, fromFlag, toFlag}},
+		{name: "import", run: runImport, args: "<file>", argKind: argFile, summary: "Import tasks from an iCalendar or todo.txt file (- for stdin)",
+			flags: []flagDef{formatFlag, {name: "--context", value: "<name>", kind: argContext, help: "Put every task in this context"}}},
+		{name: "backup", run: runBackup, summary: "Write a JSON backup of every task, event and context to stdout", subs: []*command{
+			{name: "list", summary: "List the automatic snapshots"},
+			{name: "restore", args: "<snapshot>", argKind: argSnapshot, summary: "Put a snapshot back in place of the database", flags: []flagDef{yesFlag}},
+		}},
+		{name: "restore", run: runRestore, args: "<file>", argKind: argFile, summary: "Restore a JSON backup (- for stdin)",
+			flags: []flagDef{
+				{name: "--merge", help: "Add tasks that aren't already there (the default)"},
+				{name: "--replace", help: "Replace everything with the backup; needs --yes"},
+				{name: "--yes", help: "Confirm --replace"},
+			}},
+		{name: "journal", run: runJournal, summary: "Write each day's tasks as a Markdown file",
+			flags: []flagDef{
+				{name: "--from", kind: argDate, help: "First day to write (default: --to)"},
+				{name: "--to", kind: argDate, help: "Last day to write (default: today)"},
+				{name: "--dir", value: "<directory>", kind: argDir, help: "Where to write the files (default: journal)"},
+				contextFlag, {name: "--all-contexts", help: "Write every context, one subdirectory each"},
+			}},
+		{name: "serve", run: runServe, summary: "Serve the REST JSON API and the web UI",
+			flags: []flagDef{
+				{name: "--addr", value: "<host:port>", help: "Address to listen on (default: 127.0.0.1:8080)"},
+				{name: "--token", value: "<secret>", help: "Bearer token every API request must carry (or set GTD_API_TOKEN)"},
+			}},
+		{name: "capture", run: runCapture, args: "<text>|-", summary: "Capture items into the inbox, one per line from stdin with -",
+			flags: []flagDef{contextFlag}},
+		{name: "inbox", run: runInbox, summary: "List the items waiting in the inbox, or in the backlog",
+			flags: []flagDef{{name: "--backlog", help: "List the backlog instead"}, contextFlag, allContextsFlag}},
+		{name: "done", run: runDone, args: "<id>...", argKind: argTaskID, summary: "Mark tasks done by ID"},
+		{name: "completion", run: runCompletion, args: "<shell>", argKind: argShell, noStore: true,
+			summary: "Print a completion script for bash, zsh, fish or powershell"},
+		{name: "man", run: runMan, noStore: true, summary: "Print the manual page in man(1) format"},
+		{name: "help", run: runHelp, args: "[command]", argKind: argCommand, noStore: true, summary: "Show help for gtd or one of its commands"},
+		{name: "__complete", noStore: true, hidden: true, summary: "Complete a command line, for the completion scripts"},
+	}
+	linkCommands(rootCommand, "")
+}
+
+func linkCommands(c *command, parent string) {
+	c.path = strings.TrimSpace(parent + " " + c.name)
+	for _, sub := range c.subs {
+		linkCommands(sub, c.path)
+	}
+}
+
+// sub finds a visible subcommand by name.
+func (c *command) sub(name string) *command {
+	for _, sub := range c.subs {
+		if sub.name == name && !sub.hidden {
+			return sub
+		}
+	}
+	return nil
+}
+
+// flag finds one of the command's flags, or a global flag.
+func (c *command) flag(name string) *flagDef {
+	for _, flags := range [][]flagDef{c.flags, globalFlags} {
+		for i := range flags {
+			if flags[i].name == name {
+				return &flags[i]
+			}
+		}
+	}
+	return nil
+}
+
+// findCommand looks up a command by its path below gtd, e.g. "backup restore".
+func findCommand(path string) *command {
+	c := rootCommand
+	for _, name := range strings.Fields(path) {
+		if c = c.sub(name); c == nil {
+			return nil
+		}
+	}
+	return c
+}
+
+// synopsis is the command's one-line usage, e.g. "gtd stats [--context <name>] [--since dd/mm/yyyy]".
+func (c *command) synopsis() string {
+	parts := []string{c.path}
+	if c.args != "" {
+		arg := c.args
+		if c.argKind == argDate {
+			arg = "[" + config.DateFormat + "]"
+		}
+		parts = append(parts, arg)
+	}
+	for _, f := range c.flags {
+		if f.required {
+			parts = append(parts, f.String())
+		} else {
+			parts = append(parts, "["+f.String()+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// usage is the usage text shown with argument errors: the synopsis, or one line per subcommand.
+func (c *command) usage() string {
+	if len(c.subs) == 0 || c == rootCommand {
+		return "usage: " + c.synopsis()
+	}
+	var lines []string
+	if !c.needSub {
+		lines = append(lines, c.synopsis())
+	}
+	for _, sub := range c.subs {
+		lines = append(lines, sub.synopsis())
+	}
+	return "Usage:\n  " + strings.Join(lines, "\n  ")
+}
+
+// commandUsage is the usage text for the command at path, for the commands' own errors.
+func commandUsage(path string) string {
+	return findCommand(path).usage()
+}
+
+// writeHelp prints the --help text for a command.
+func writeHelp(w io.Writer, c *command) {
+	fmt.Fprintf(w, "%s\n\nUsage:\n", c.summary)
+	if c == rootCommand {
+		fmt.Fprintf(w, "  %s\n  gtd <command> [arguments]\n", c.synopsis())
+	} else {
+		if !c.needSub {
+			fmt.Fprintf(w, "  %s\n", c.synopsis())
+		}
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, "  %s\n", sub.synopsis())
+		}
+	}
+
+	if c.subs != nil {
+		fmt.Fprintln(w, "\nCommands:")
+		writeTable(w, c.subs, func(sub *command) (string, string) { return sub.name, sub.summary })
+	}
+	if len(c.flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		writeTable(w, c.flags, func(f flagDef) (string, string) { return f.String(), f.help })
+	}
+	fmt.Fprintln(w, "\nGlobal flags:")
+	writeTable(w, globalFlags, func(f flagDef) (string, string) { return f.String(), f.help })
+	if c == rootCommand {
+		fmt.Fprintln(w, "\nRun \"gtd help <command>\" for more about a command.")
+	}
+}
+
+// writeTable prints two aligned columns, skipping hidden commands.
+func writeTable[T any](w io.Writer, items []T, row func(T) (string, string)) {
+	var names, helps []string
+	width := 0
+	for _, item := range items {
+		if c, ok := any(item).(*command); ok && c.hidden {
+			continue
+		}
+		name, help := row(item)
+		names, helps = append(names, name), append(helps, help)
+		width = max(width, len(name))
+	}
+	for i := range names {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, names[i], helps[i])
+	}
+}
+
+// wantsHelp reports whether the arguments ask for help rather than running the command.
+func wantsHelp(args []string) bool {
+	return slices.Contains(args, "--help") || slices.Contains(args, "-h")
+}
+
+// helpTarget picks the command that "gtd <command> <args> --help" is asking about, so
+// "gtd backup restore --help" explains restore rather than backup.
+func helpTarget(c *command, args []string) *command {
+	for _, arg := range args {
+		if sub := c.sub(arg); sub != nil {
+			return helpTarget(sub, nil)
+		}
+	}
+	return c
+}
+
+// runHelp implements "gtd help [command]".
+func runHelp(_ *Store, args []string) error {
+	c := findCommand(strings.Join(args, " "))
+	if c == nil {
+		return fmt.Errorf("unknown command %q\n%s", strings.Join(args, " "), rootCommand.usage())
+	}
+	writeHelp(os.Stdout, c)
+	return nil
+}
+
+// runDone implements "gtd done <id>...", marking each task done.
+func runDone(store *Store, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", commandUsage("done"))
+	}
+	var ids []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad task id %q\n%s", arg, commandUsage("done"))
+		}
+		if _, err := store.GetTask(id); err != nil {
+			return fmt.Errorf("no task with id %d", id)
+		}
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if err := store.MarkComplete(id); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Marked %d task(s) done.\n", len(ids))
+	return nil
+}
+
+// runMan implements "gtd man", writing a man(1) page generated from the command tree.
+func runMan(_ *Store, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown argument %q\n%s", args[0], commandUsage("man"))
+	}
+	writeManPage(os.Stdout)
+	return nil
+}
+
+func writeManPage(w io.Writer) {
+	fmt.Fprintln(w, `.TH GTD 1 "" "sysadmin-gtd" "User Commands"`)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `gtd \- `+roff(rootCommand.summary))
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B gtd\n%s\n.br\n.B gtd\n<command> [arguments]\n", roff(strings.TrimPrefix(rootCommand.synopsis(), "gtd ")))
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, "Plan the day with prioritised tasks, track progress and carry unfinished work forward.")
+	fmt.Fprintln(w, "With no command, gtd opens the day's tasks in an interactive table; press ? there for the keys.")
+	fmt.Fprintln(w, "A date, in the configured date_format, opens that day instead.")
+	fmt.Fprintln(w, ".SH OPTIONS")
+	writeManFlags(w, rootCommand.flags)
+	writeManFlags(w, globalFlags)
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, c := range rootCommand.subs {
+		if c.hidden {
+			continue
+		}
+		if !c.needSub {
+			fmt.Fprintf(w, ".SS %s\n%s\n", roff(c.synopsis()), roff(c.summary+"."))
+			writeManFlags(w, c.flags)
+		}
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, ".SS %s\n%s\n", roff(sub.synopsis()), roff(sub.summary+"."))
+			writeManFlags(w, sub.flags)
+		}
+	}
+
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, env := range [][2]string{
+		{"GTD_DB", "Database file, when --db isn't given."},
+		{"GTD_API_TOKEN", "Bearer token for gtd serve, when --token isn't given."},
+		{"NO_COLOR", "Use the monochrome theme."},
+	} {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", env[0], roff(env[1]))
+	}
+	fmt.Fprintln(w, ".SH FILES")
+	fmt.Fprintln(w, ".TP\n.I config.toml\nSettings, in the sysadmin\\-gtd folder of the platform config directory.")
+	fmt.Fprintln(w, ".TP\n.I hooks/\nHook scripts, next to config.toml.")
+	fmt.Fprintln(w, ".TP\n.I tasks.db\nThe SQLite database, in the sysadmin\\-gtd folder of the platform data directory, with snapshots in backups/ beside it.")
+}
+
+func writeManFlags(w io.Writer, flags []flagDef) {
+	for _, f := range flags {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roff(f.String()), roff(f.help+"."))
+	}
+}
+
+// roff escapes text for a man page: backslashes, hyphens (so flags copy and paste), and
+// a leading dot or quote that would read as a request.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandTree(t *testing.T) {
+	seen := map[string]bool{}
+	var walk func(c *command)
+	walk = func(c *command) {
+		if seen[c.path] {
+			t.Errorf("duplicate command %q", c.path)
+		}
+		seen[c.path] = true
+		if c.summary == "" {
+			t.Errorf("%s has no summary", c.path)
+		}
+		if c != rootCommand && c.run == nil && !c.hidden && !strings.Contains(c.path, " ") {
+			t.Errorf("%s has nothing to run it", c.path)
+		}
+		for _, f := range c.flags {
+			if !strings.HasPrefix(f.name, "--") || f.help == "" {
+				t.Errorf("%s: bad flag %+v", c.path, f)
+			}
+		}
+		for _, sub := range c.subs {
+			walk(sub)
+		}
+	}
+	walk(rootCommand)
+
+	if c := findCommand("backup restore"); c == nil || c.path != "gtd backup restore" {
+		t.Errorf("findCommand(backup restore) = %+v", c)
+	}
+	if findCommand("__complete") != nil || findCommand("nope") != nil {
+		t.Error("hidden and unknown commands shouldn't be found")
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	tests := map[string]string{
+		"stats":          "usage: gtd stats [--context <name>] [--since dd/mm/yyyy]",
+		"export":         "usage: gtd export --format ics|todotxt [--context <name>] [--all-contexts] [--from dd/mm/yyyy] [--to dd/mm/yyyy]",
+		"import":         "usage: gtd import <file> [--format ics|todotxt] [--context <name>]",
+		"backup restore": "usage: gtd backup restore <snapshot> --yes",
+		"config":         "Usage:\n  gtd config show",
+		"backup":         "Usage:\n  gtd backup\n  gtd backup list\n  gtd backup restore <snapshot> --yes",
+		"completion":     "usage: gtd completion <shell>",
+	}
+	for path, want := range tests {
+		if got := commandUsage(path); got != want {
+			t.Errorf("commandUsage(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if got := rootCommand.usage(); !strings.HasPrefix(got, "usage: gtd [dd/mm/yyyy] [--print]") {
+		t.Errorf("root usage = %q", got)
+	}
+
+	cfg := defaultConfig()
+	cfg.DateFormat = "yyyy-mm-dd"
+	useConfig(t, cfg)
+	if got := commandUsage("stats"); !strings.HasSuffix(got, "[--since yyyy-mm-dd]") {
+		t.Errorf("expected the configured date format, got %q", got)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	out := captureOutput(t, func() error { return runHelp(nil, nil) })
+	for _, c := range rootCommand.subs {
+		if strings.Contains(out, "\n  "+c.name+" ") == c.hidden {
+			t.Errorf("command %s listed wrongly in:\n%s", c.name, out)
+		}
+	}
+	for _, want := range []string{"Usage:\n  gtd [dd/mm/yyyy] [--print]", "--due-within <3d|2w>", "Global flags:\n  --config <file>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	out = captureOutput(t, func() error { return runHelp(nil, []string{"context"}) })
+	if !strings.Contains(out, "Usage:\n  gtd context rename <old> <new>\n") || !strings.Contains(out, "Commands:\n  rename ") {
+		t.Errorf("unexpected context help:\n%s", out)
+	}
+	if err := runHelp(nil, []string{"nope"}); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	if got := helpTarget(findCommand("backup"), []string{"restore", "--help"}); got.path != "gtd backup restore" {
+		t.Errorf("helpTarget = %s", got.path)
+	}
+	if !wantsHelp([]string{"--from", "x", "-h"}) || wantsHelp([]string{"--helpful"}) {
+		t.Error("wantsHelp got it wrong")
+	}
+}
+
+func TestManPage(t *testing.T) {
+	var b strings.Builder
+	writeManPage(&b)
+	page := b.String()
+	for _, want := range []string{
+		".TH GTD 1 ",
+		".SH SYNOPSIS",
+		".SS gtd export \\-\\-format ics|todotxt",
+		".SS gtd context delete <name> \\-\\-yes",
+		".B \\-\\-db <file>",
+		".B GTD_API_TOKEN",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %q in the man page", want)
+		}
+	}
+	if strings.Contains(page, "__complete") || strings.Contains(page, "gtd context\n") {
+		t.Error("hidden commands and bare parents shouldn't be in the man page")
+	}
+	if got := roff(`.hidden C:\tmp -x`); got != `\&.hidden C:\etmp \-x` {
+		t.Errorf("roff = %q", got)
+	}
+}
+
+func TestRunDone(t *testing.T) {
+	s := newTestStore(t)
+	a, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Order toner", Priority: PriorityC, Context: "default"})
+	b, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Fix the fence", Priority: PriorityB, Context: "home"})
+
+	msg := captureOutput(t, func() error { return runDone(s, []string{itoa(a), itoa(b)}) })
+	if msg != "Marked 2 task(s) done.\n" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	for _, id := range []int64{a, b} {
+		if task, _ := s.GetTask(id); task.Status != StatusDone {
+			t.Errorf("task %d: expected done, got %v", id, task.Status)
+		}
+	}
+
+	c, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Still open", Priority: PriorityC, Context: "default"})
+	for _, args := range [][]string{nil, {"abc"}, {itoa(c), "999"}} {
+		if err := runDone(s, args); err == nil {
+			t.Errorf("runDone(%v): expected an error", args)
+		}
+	}
+	if task, _ := s.GetTask(c); task.Status != StatusTodo {
+		t.Error("nothing should be marked done when any ID is bad")
+	}
+}
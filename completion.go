@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Shell completion works the same way for every shell: a short script, printed by
+// "gtd completion <shell>", passes the words typed so far to the hidden "gtd __complete"
+// command, which walks the command tree and prints one candidate per line as
+// "value<TAB>description". Context names, today's task IDs and snapshot names come from the
+// database. A line reading ":file" or ":dir" tells the script to complete paths instead.
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+type candidate struct {
+	value, help string
+}
+
+// runCompletion implements "gtd completion bash|zsh|fish|powershell".
+func runCompletion(_ *Store, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", commandUsage("completion"))
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown shell %q (available: %s)", args[0], strings.Join(completionShells, ", "))
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runComplete implements "gtd __complete <word>... <current word>". It is given the raw command
+// line, so it handles --config and --db itself rather than letting main strip them: the word
+// being completed may be one of them. Errors are kept quiet, since they'd land in the prompt.
+func runComplete(words []string) {
+	if len(words) == 0 {
+		return
+	}
+	typed := words[:len(words)-1]
+	if path, _, _ := takeGlobalFlag(typed, "--config"); path != "" {
+		if cfg, err := loadConfig(path, true); err == nil {
+			config = cfg
+		}
+	} else if path, err := defaultConfigPath(); err == nil {
+		if cfg, err := loadConfig(path, false); err == nil {
+			config = cfg
+		}
+	}
+
+	var store *Store
+	dbFlag, _, _ := takeGlobalFlag(typed, "--db")
+	if path, legacy, err := locateDB(dbFlag); err == nil {
+		// Read only: completion mustn't create, move, migrate or snapshot the database, and
+		// without one there are just no dynamic candidates
+		if legacy != "" {
+			path = legacy
+		}
+		if store, err = NewReadOnlyStore(path); err == nil {
+			defer store.Close()
+		}
+	}
+
+	candidates, directive := complete(store, words)
+	if directive != "" {
+		fmt.Println(directive)
+		return
+	}
+	for _, c := range candidates {
+		if c.help != "" {
+			fmt.Printf("%s\t%s\n", c.value, c.help)
+		} else {
+			fmt.Println(c.value)
+		}
+	}
+}
+
+// complete returns the candidates for the last of words, given the ones before it, or a
+// directive (":file" or ":dir") for the shell to complete paths. store may be nil.
+func complete(store *Store, words []string) ([]candidate, string) {
+	cur := words[len(words)-1]
+	c := rootCommand
+	positional := 0
+	var pending *flagDef // a flag still waiting for its value
+	for _, w := range words[:len(words)-1] {
+		if pending != nil {
+			if w != "=" { // bash splits --flag=value into three words
+				pending = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(w, "-") && w != "-" {
+			name, _, inline := strings.Cut(w, "=")
+			if f := c.flag(name); f != nil && f.takesValue() && !inline {
+				pending = f
+			}
+			continue
+		}
+		if sub := c.sub(w); sub != nil && positional == 0 {
+			c = sub
+			continue
+		}
+		positional++
+	}
+
+	if pending != nil {
+		return completeValue(store, pending.kind, strings.TrimPrefix(cur, "="), "")
+	}
+	if strings.HasPrefix(cur, "-") {
+		if name, prefix, ok := strings.Cut(cur, "="); ok {
+			if f := c.flag(name); f != nil && f.takesValue() {
+				return completeValue(store, f.kind, prefix, name+"=")
+			}
+			return nil, ""
+		}
+		var candidates []candidate
+		for _, flags := range [][]flagDef{c.flags, globalFlags} {
+			for _, f := range flags {
+				if strings.HasPrefix(f.name, cur) {
+					candidates = append(candidates, candidate{f.name, f.help})
+				}
+			}
+		}
+		return candidates, ""
+	}
+	if len(c.subs) > 0 && positional == 0 {
+		var candidates []candidate
+		for _, sub := range c.subs {
+			if !sub.hidden && strings.HasPrefix(sub.name, cur) {
+				candidates = append(candidates, candidate{sub.name, sub.summary})
+			}
+		}
+		return candidates, ""
+	}
+	if c.args == "" {
+		return nil, ""
+	}
+	return completeValue(store, c.argKind, cur, "")
+}
+
+// completeValue lists the values of a kind that start with prefix, each with insert in front
+// (for --flag=value).
+func completeValue(store *Store, kind argKind, prefix, insert string) ([]candidate, string) {
+	var all []candidate
+	switch kind {
+	case argFile:
+		return nil, ":file"
+	case argDir:
+		return nil, ":dir"
+	case argFormat:
+		for _, name := range exportFormatNames() {
+			all = append(all, candidate{name, ""})
+		}
+	case argShell:
+		for _, name := range completionShells {
+			all = append(all, candidate{name, ""})
+		}
+	case argCommand:
+		for _, c := range rootCommand.subs {
+			if !c.hidden {
+				all = append(all, candidate{c.name, c.summary})
+			}
+		}
+	case argContext:
+		if store == nil {
+			break
+		}
+		contexts, _ := store.ListContexts()
+		for _, ctx := range contexts {
+			help := fmt.Sprintf("%d open", ctx.Open)
+			if ctx.Archived {
+				help += ", archived"
+			}
+			all = append(all, candidate{ctx.Name, help})
+		}
+	case argTaskID:
+		if store == nil {
+			break
+		}
+		tasks, _ := store.GetTasksForDateAllContexts(store.now().Format("2006-01-02"))
+		for _, t := range tasks {
+			help := fmt.Sprintf("%s %s (%s)", t.Priority, t.Description, t.Context)
+			if t.Status != StatusTodo {
+				help += " " + t.Status.Name()
+			}
+			all = append(all, candidate{strconv.FormatInt(t.ID, 10), help})
+		}
+	case argSnapshot:
+		if store == nil {
+			break
+		}
+		snapshots, _ := store.ListSnapshots()
+		for _, snap := range snapshots {
+			all = append(all, candidate{filepath.Base(snap.Path), "before " + snap.Reason})
+		}
+	}
+
+	var candidates []candidate
+	for _, c := range all {
+		if strings.HasPrefix(c.value, prefix) {
+			candidates = append(candidates, candidate{insert + c.value, c.help})
+		}
+	}
+	return candidates, ""
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gtd. Load it with: source <(gtd completion bash)
+_gtd() {
+    local cur=${COMP_WORDS[COMP_CWORD]} line
+    COMPREPLY=()
+    while IFS= read -r line; do
+        case $line in
+        :file) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur")); return ;;
+        :dir) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -d -- "$cur")); return ;;
+        esac
+        COMPREPLY+=("${line%%$'\t'*}")
+    done < <(gtd __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+}
+complete -F _gtd gtd
+`,
+
+	"zsh": `#compdef gtd
+# zsh completion for gtd. Load it with: source <(gtd completion zsh)
+# or save it as _gtd in a directory on your $fpath.
+_gtd() {
+    local -a lines values
+    local line
+    lines=("${(@f)$(gtd __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    case ${lines[1]} in
+    :file) _files; return ;;
+    :dir) _files -/; return ;;
+    esac
+    for line in $lines; do
+        [[ -n $line ]] || continue
+        if [[ $line == *$'\t'* ]]; then
+            values+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        else
+            values+=("${line//:/\\:}")
+        fi
+    done
+    _describe gtd values
+}
+if [[ $funcstack[1] == _gtd ]]; then
+    _gtd "$@"
+else
+    compdef _gtd gtd
+fi
+`,
+
+	"fish": `# fish completion for gtd. Load it with: gtd completion fish | source
+function __gtd_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l cur (commandline -ct)
+    set -l out (gtd __complete $words "$cur" 2>/dev/null)
+    switch "$out[1]"
+        case :file
+            __fish_complete_path "$cur"
+        case :dir
+            __fish_complete_directories "$cur"
+        case '*'
+            printf '%s\n' $out
+    end
+end
+complete -c gtd -f -a '(__gtd_complete)'
+`,
+
+	"powershell": `# PowerShell completion for gtd (PowerShell 7.3 or later).
+# Load it with: gtd completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName gtd -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '' }
+    $lines = @(& gtd __complete @words 2>$null)
+    if ($lines.Count -gt 0 -and ($lines[0] -eq ':file' -or $lines[0] -eq ':dir')) { return }
+    foreach ($line in $lines) {
+        $value, $help = $line -split "` + "`t" + `", 2
+        if (-not $help) { $help = $value }
+        $text = if ($value -match '\s') { "'$value'" } else { $value }
+        [System.Management.Automation.CompletionResult]::new($text, $value, 'ParameterValue', $help)
+    }
+}
+`,
+}
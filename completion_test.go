@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func completions(t *testing.T, s *Store, words ...string) string {
+	t.Helper()
+	candidates, directive := complete(s, words)
+	if directive != "" {
+		return directive
+	}
+	var values []string
+	for _, c := range candidates {
+		values = append(values, c.value)
+	}
+	return strings.Join(values, " ")
+}
+
+func TestComplete(t *testing.T) {
+	s := newTestStore(t)
+	s.now = func() time.Time { return time.Date(2025, 6, 2, 9, 0, 0, 0, time.Local) }
+	work, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Patch web servers", Priority: PriorityA, Context: "work"})
+	home, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Fix the fence", Priority: PriorityB, Context: "home"})
+	s.AddTask("2025-06-01", "Yesterday's task", PriorityB, "", "work")
+	s.SetContextArchived("home", true)
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"co"}, "contexts context config completion"},
+		{[]string{"--db", "x.db", "sta"}, "stats"},
+		{[]string{"--pr"}, "--print"},
+		{[]string{"export", "--f"}, "--format --from"},
+		{[]string{"export", "--format", ""}, "ics todotxt"},
+		{[]string{"export", "--format=t"}, "--format=todotxt"},
+		{[]string{"export", "--format", "=", ""}, "ics todotxt"}, // bash splits at =
+		{[]string{"stats", "--context", "w"}, "work"},
+		{[]string{"--context", ""}, "home work"},
+		{[]string{"context", "merge", "work", "h"}, "home"},
+		{[]string{"context", ""}, "rename merge archive unarchive delete"},
+		{[]string{"context", "delete", "work", "--"}, "--yes --config --db --help"},
+		{[]string{"done", ""}, itoa(home) + " " + itoa(work)},
+		{[]string{"done", itoa(work), ""}, itoa(home) + " " + itoa(work)},
+		{[]string{"import", "ta"}, ":file"},
+		{[]string{"journal", "--dir", ""}, ":dir"},
+		{[]string{"--db", ""}, ":file"},
+		{[]string{"completion", "f"}, "fish"},
+		{[]string{"help", "ba"}, "backup"},
+		{[]string{"search", "toner", ""}, ""},
+		{[]string{"stats", "--since", ""}, ""},
+		{[]string{"__compl"}, ""},
+	}
+	for _, tt := range tests {
+		if got := completions(t, s, tt.words...); got != tt.want {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+
+	// Today's tasks from every context, described by their names
+	candidates, _ := complete(s, []string{"done", ""})
+	if candidates[1].help != "A Patch web servers (work)" {
+		t.Errorf("expected the task as the ID's description, got %q", candidates[1].help)
+	}
+	if candidates, _ := complete(s, []string{"--context", "h"}); candidates[0].help != "1 open, archived" {
+		t.Errorf("unexpected context description %q", candidates[0].help)
+	}
+
+	// Without a database there's nothing to offer, but no failure either
+	if got := completions(t, nil, "--context", ""); got != "" {
+		t.Errorf("expected no contexts without a database, got %q", got)
+	}
+}
+
+func TestCompleteSnapshots(t *testing.T) {
+	s := newFileStore(t)
+	s.AddTask("2025-06-01", "Old task", PriorityB, "", "default")
+	tasks, _ := s.GetTasksForDate("2025-06-01", "default")
+	s.DeleteTask(tasks[0].ID)
+
+	got := completions(t, s, "backup", "restore", "tasks-")
+	if !strings.HasPrefix(got, "tasks-20250601T") || !strings.HasSuffix(got, "-delete.db") {
+		t.Errorf("expected the snapshot's name, got %q", got)
+	}
+}
+
+func TestRunCompleteReadOnly(t *testing.T) {
+	useConfig(t, defaultConfig())
+	noConfig := filepath.Join(t.TempDir(), "missing.toml")
+	run := func(db, cur string) string {
+		return captureOutput(t, func() error {
+			runComplete([]string{"--config", noConfig, "--db", db, "--context", cur})
+			return nil
+		})
+	}
+
+	missing := filepath.Join(t.TempDir(), "tasks.db")
+	if out := run(missing, ""); out != "" {
+		t.Errorf("expected no candidates without a database, got %q", out)
+	}
+	if _, err := os.Stat(missing); err == nil {
+		t.Error("completion shouldn't create the database")
+	}
+
+	// An older database is read as it is, with no migration or snapshot
+	s := newFileStore(t)
+	s.AddTask("2025-06-01", "Patch web servers", PriorityA, "", "work")
+	s.db.Exec(`PRAGMA user_version = 1`)
+	s.Close()
+	if out := run(s.path, "w"); out != "work\t1 open\n" {
+		t.Errorf("unexpected candidates %q", out)
+	}
+	s, _ = NewReadOnlyStore(s.path)
+	defer s.Close()
+	var version int
+	s.db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	if snapshots, _ := s.ListSnapshots(); version != 1 || len(snapshots) != 0 {
+		t.Errorf("expected the database untouched, got version %d and %d snapshot(s)", version, len(snapshots))
+	}
+}
+
+func TestRunCompleteLeavesLegacyDatabase(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the default location only differs from the old one on Linux")
+	}
+	useConfig(t, defaultConfig())
+	configHome, dataHome := t.TempDir(), t.TempDir()
+	t.Setenv("GTD_DB", "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	legacy := filepath.Join(configHome, "sysadmin-gtd", "tasks.db")
+	s, err := NewStore(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTask("2025-06-01", "Patch web servers", PriorityA, "", "work")
+	s.Close()
+
+	out := captureOutput(t, func() error {
+		runComplete([]string{"--context", "w"})
+		return nil
+	})
+	if out != "work\t1 open\n" {
+		t.Errorf("expected contexts read from the old location, got %q", out)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Error("completion shouldn't move the database")
+	}
+	if _, err := os.Stat(filepath.Join(dataHome, "sysadmin-gtd")); !os.IsNotExist(err) {
+		t.Error("completion shouldn't create the new location")
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range completionShells {
+		script := captureOutput(t, func() error { return runCompletion(nil, []string{shell}) })
+		if !strings.Contains(script, "gtd __complete") {
+			t.Errorf("%s script doesn't call gtd __complete", shell)
+		}
+	}
+	for _, args := range [][]string{nil, {"tcsh"}, {"bash", "zsh"}} {
+		if err := runCompletion(nil, args); err == nil {
+			t.Errorf("runCompletion(%v): expected an error", args)
+		}
+	}
+
+	// Check the scripts parse, for the shells that are installed
+	for shell, check := range map[string][]string{"bash": {"-n"}, "zsh": {"-n"}, "fish": {"--no-execute"}} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		file := filepath.Join(t.TempDir(), "gtd."+shell)
+		os.WriteFile(file, []byte(completionScripts[shell]), 0o644)
+		if out, err := exec.Command(path, append(check, file)...).CombinedOutput(); err != nil {
+			t.Errorf("%s script doesn't parse: %v\n%s", shell, err, out)
+		}
+	}
+}
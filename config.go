@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the user's settings, read from config.toml in the sysadmin-gtd config directory.
+// Every setting is optional; anything left out keeps its default.
+type Config struct {
+	DefaultContext     string              `toml:"default_context"`
+	DefaultPriority    Priority            `toml:"default_priority"`
+	DateFormat         string              `toml:"date_format"`          // dd, mm and yyyy in any order, e.g. "mm/dd/yyyy"
+	WeekStart          string              `toml:"week_start"`           // day name, e.g. "monday" or "sunday"
+	WorkingHours       float64             `toml:"working_hours"`        // how long "1d" means in a time estimate
+	AutoRollover       bool                `toml:"auto_rollover"`        // carry open tasks to today when the TUI starts
+	PomodoroMinutes    int                 `toml:"pomodoro_minutes"`     // length of a focus interval
+	BreakMinutes       int                 `toml:"break_minutes"`        // length of the break between intervals
+	SnapshotKeepDays   int                 `toml:"snapshot_keep_days"`   // delete snapshots older than this; 0 keeps them however old
+	SnapshotKeepCopies int                 `toml:"snapshot_keep_copies"` // how many snapshots to keep; 0 turns them off
+	HookTimeout        int                 `toml:"hook_timeout"`         // seconds a hook may run before it's stopped
+	Theme              string              `toml:"theme"`
+	Colors             ThemeColors         `toml:"colors,omitempty"` // custom colours over the theme
+	Keymap             string              `toml:"keymap"`           // key binding preset: default, vim or emacs
+	Keys               map[string][]string `toml:"keys,omitempty"`   // per-action key overrides over the preset
+	Hooks              map[string][]string `toml:"hooks,omitempty"`  // event (or "*") → URLs to POST it to
+}
+
+// config is the effective configuration. main replaces it with the loaded file; tests use the defaults.
+var config = defaultConfig()
+
+// configPath is where config was loaded from, for "gtd config show".
+var configPath string
+
+func defaultConfig() Config {
+	return Config{
+		DefaultContext:     "default",
+		DefaultPriority:    PriorityB,
+		DateFormat:         "dd/mm/yyyy",
+		WeekStart:          "monday",
+		WorkingHours:       8,
+		PomodoroMinutes:    25,
+		BreakMinutes:       5,
+		SnapshotKeepDays:   30,
+		SnapshotKeepCopies: 20,
+		HookTimeout:        10,
+		Theme:              "default",
+		Keymap:             "default",
+	}
+}
+
+// defaultConfigPath is config.toml in the platform config directory.
+func defaultConfigPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("config dir: %w", err)
+	}
+	return filepath.Join(configDir, "sysadmin-gtd", "config.toml"), nil
+}
+
+// loadConfig reads the TOML file at path over the defaults. A missing file just means defaults,
+// unless the path was given explicitly with --config.
+func loadConfig(path string, explicit bool) (Config, error) {
+	cfg := defaultConfig()
+	md, err := toml.DecodeFile(path, &cfg)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("config %s: unknown setting %q", path, undecoded[0].String())
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// validate checks every setting and normalises case where it doesn't matter.
+func (c *Config) validate() error {
+	c.DefaultContext = strings.TrimSpace(c.DefaultContext)
+	if c.DefaultContext == "" {
+		return fmt.Errorf("default_context can't be empty")
+	}
+
+	c.DefaultPriority = Priority(strings.ToUpper(string(c.DefaultPriority)))
+	switch c.DefaultPriority {
+	case PriorityA, PriorityB, PriorityC, PriorityD:
+	default:
+		return fmt.Errorf("default_priority must be A, B, C or D, got %q", c.DefaultPriority)
+	}
+
+	c.DateFormat = strings.ToLower(c.DateFormat)
+	for _, part := range []string{"dd", "mm", "yyyy"} {
+		if strings.Count(c.DateFormat, part) != 1 {
+			return fmt.Errorf("date_format must contain dd, mm and yyyy once each, got %q", c.DateFormat)
+		}
+	}
+
+	c.WeekStart = strings.ToLower(c.WeekStart)
+	if _, ok := parseWeekday(c.WeekStart); !ok {
+		return fmt.Errorf("week_start must be a day name such as monday or sunday, got %q", c.WeekStart)
+	}
+
+	if c.WorkingHours <= 0 || c.WorkingHours > 24 {
+		return fmt.Errorf("working_hours must be between 0 and 24, got %g", c.WorkingHours)
+	}
+
+	if c.PomodoroMinutes < 1 || c.PomodoroMinutes > 240 {
+		return fmt.Errorf("pomodoro_minutes must be between 1 and 240, got %d", c.PomodoroMinutes)
+	}
+	if c.BreakMinutes < 1 || c.BreakMinutes > 120 {
+		return fmt.Errorf("break_minutes must be between 1 and 120, got %d", c.BreakMinutes)
+	}
+	if c.SnapshotKeepDays < 0 {
+		return fmt.Errorf("snapshot_keep_days can't be negative, got %d", c.SnapshotKeepDays)
+	}
+	if c.SnapshotKeepCopies < 0 {
+		return fmt.Errorf("snapshot_keep_copies can't be negative, got %d", c.SnapshotKeepCopies)
+	}
+	if c.HookTimeout < 1 || c.HookTimeout > 300 {
+		return fmt.Errorf("hook_timeout must be between 1 and 300 seconds, got %d", c.HookTimeout)
+	}
+	if err := validateHooks(c.Hooks); err != nil {
+		return err
+	}
+
+	c.Theme = strings.ToLower(c.Theme)
+	if _, ok := themes[c.Theme]; !ok {
+		return fmt.Errorf("unknown theme %q (available: %s)", c.Theme, strings.Join(themeNames, ", "))
+	}
+	if err := c.Colors.validate(); err != nil {
+		return err
+	}
+
+	c.Keymap = strings.ToLower(c.Keymap)
+	if !slices.Contains(keymapNames, c.Keymap) {
+		return fmt.Errorf("unknown keymap %q (available: %s)", c.Keymap, strings.Join(keymapNames, ", "))
+	}
+	return validateKeys(c.Keymap, c.Keys)
+}
+
+// dateLayout is the Go time layout for DateFormat, used to read and show dates.
+func (c Config) dateLayout() string {
+	return strings.NewReplacer("yyyy", "2006", "dd", "02", "mm", "01").Replace(c.DateFormat)
+}
+
+// shortDateLayout is dateLayout without the year, e.g. "02/01" for dd/mm/yyyy. The year goes
+// with one separator next to it, if there is one: "0102" for yyyymmdd.
+func (c Config) shortDateLayout() string {
+	layout := c.dateLayout()
+	i := strings.Index(layout, "2006")
+	if i < 0 {
+		return layout
+	}
+	j := i + 4
+	if r, size := utf8.DecodeLastRuneInString(layout[:i]); i > 0 && !unicode.IsDigit(r) {
+		i -= size
+	} else if r, size := utf8.DecodeRuneInString(layout[j:]); j < len(layout) && !unicode.IsDigit(r) {
+		j += size
+	}
+	return layout[:i] + layout[j:]
+}
+
+// pomodoro is how long a focus interval lasts.
+func (c Config) pomodoro() time.Duration {
+	return time.Duration(c.PomodoroMinutes) * time.Minute
+}
+
+// breakLength is how long the break between focus intervals lasts.
+func (c Config) breakLength() time.Duration {
+	return time.Duration(c.BreakMinutes) * time.Minute
+}
+
+// weekStart is the first day of the week for weekly groupings.
+func (c Config) weekStart() time.Weekday {
+	day, _ := parseWeekday(c.WeekStart)
+	return day
+}
+
+// hookTimeout is how long a hook may run.
+func (c Config) hookTimeout() time.Duration {
+	return time.Duration(c.HookTimeout) * time.Second
+}
+
+// workingDay is how long a "1d" time estimate is.
+func (c Config) workingDay() time.Duration {
+	return time.Duration(c.WorkingHours * float64(time.Hour))
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// takeGlobalFlag removes a flag such as --config from the command line, wherever it appears,
+// so it works for the TUI and every subcommand alike. value is "" when the flag isn't given.
+func takeGlobalFlag(args []string, name string) (value string, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		v, next, ok, err := takeFlag(args, i, name)
+		if err != nil {
+			return "", nil, err
+		}
+		if ok {
+			value, i = v, next
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	return value, rest, nil
+}
+
+// runConfig implements "gtd config show". It only reports where the database is, so it
+// resolves the path without opening (and so creating or migrating) the database.
+func runConfig(_ *Store, args []string) error {
+	if len(args) != 1 || args[0] != "show" {
+		return fmt.Errorf("%s", commandUsage("config"))
+	}
+
+	source := configPath
+	if _, err := os.Stat(configPath); err != nil {
+		source += " (not found, using defaults)"
+	}
+	dbPath, movedFrom, err := resolveDBPath(dbFlag)
+	if err != nil {
+		return err
+	}
+	if movedFrom != "" {
+		fmt.Fprintf(os.Stderr, "Moved database from %s to %s\n", movedFrom, dbPath)
+	}
+	fmt.Printf("# config: %s\n", source)
+	fmt.Printf("# database: %s\n", dbPath)
+	return toml.NewEncoder(os.Stdout).Encode(config)
+}
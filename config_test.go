@@ -0,0 +1,254 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useConfig replaces the package config for the duration of a test.
+func useConfig(t *testing.T, cfg Config) {
+	t.Helper()
+	old := config
+	config = cfg
+	t.Cleanup(func() { config = old })
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+default_context = "work"
+default_priority = "a"
+date_format = "MM/DD/YYYY"
+week_start = "Sunday"
+working_hours = 7.5
+auto_rollover = true
+pomodoro_minutes = 50
+
+[hooks]
+"task.done" = ["https://chat.example.com/hooks/abc123"]
+`)
+
+	cfg, err := loadConfig(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		DefaultContext:     "work",
+		DefaultPriority:    PriorityA,
+		DateFormat:         "mm/dd/yyyy",
+		WeekStart:          "sunday",
+		WorkingHours:       7.5,
+		AutoRollover:       true,
+		PomodoroMinutes:    50,
+		BreakMinutes:       5,
+		SnapshotKeepDays:   30,
+		SnapshotKeepCopies: 20,
+		HookTimeout:        10,
+		Theme:              "default",
+		Keymap:             "default",
+		Hooks:              map[string][]string{"task.done": {"https://chat.example.com/hooks/abc123"}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	cfg, err := loadConfig(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, defaultConfig()) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+
+	if _, err := loadConfig(path, true); err == nil {
+		t.Error("expected an error for a missing --config file")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown setting", `colour = "red"`, `unknown setting "colour"`},
+		{"bad priority", `default_priority = "E"`, "default_priority"},
+		{"bad date format", `date_format = "dd/mm"`, "date_format"},
+		{"bad week start", `week_start = "funday"`, "week_start"},
+		{"bad working hours", `working_hours = 0`, "working_hours"},
+		{"bad pomodoro", `pomodoro_minutes = 0`, "pomodoro_minutes"},
+		{"bad break", `break_minutes = 500`, "break_minutes"},
+		{"bad snapshot days", `snapshot_keep_days = -1`, "snapshot_keep_days"},
+		{"bad snapshot copies", `snapshot_keep_copies = -5`, "snapshot_keep_copies"},
+		{"bad hook timeout", `hook_timeout = 0`, "hook_timeout"},
+		{"bad hook event", "[hooks]\n\"task.finished\" = [\"https://example.com\"]", `unknown hook event "task.finished"`},
+		{"bad hook URL", "[hooks]\n\"*\" = [\"ftp://example.com\"]", "isn't an http or https URL"},
+		{"bad theme", `theme = "neon"`, `unknown theme "neon"`},
+		{"empty context", `default_context = " "`, "default_context"},
+		{"not toml", `default_context = `, "config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(writeConfig(t, tt.content), true)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDateLayouts(t *testing.T) {
+	tests := []struct {
+		format string
+		layout string
+		short  string
+	}{
+		{"dd/mm/yyyy", "02/01/2006", "02/01"},
+		{"mm/dd/yyyy", "01/02/2006", "01/02"},
+		{"yyyy-mm-dd", "2006-01-02", "01-02"},
+		{"dd.mm.yyyy", "02.01.2006", "02.01"},
+		{"yyyymmdd", "20060102", "0102"},
+		{"ddmmyyyy", "02012006", "0201"},
+		{"dd/yyyy/mm", "02/2006/01", "02/01"},
+	}
+	for _, tt := range tests {
+		cfg := Config{DateFormat: tt.format}
+		if got := cfg.dateLayout(); got != tt.layout {
+			t.Errorf("dateLayout(%q) = %q, want %q", tt.format, got, tt.layout)
+		}
+		if got := cfg.shortDateLayout(); got != tt.short {
+			t.Errorf("shortDateLayout(%q) = %q, want %q", tt.format, got, tt.short)
+		}
+	}
+}
+
+func TestParseInputDateUsesConfig(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DateFormat = "mm/dd/yyyy"
+	useConfig(t, cfg)
+
+	got, err := parseInputDate("12/25/2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2025-12-25" {
+		t.Errorf("got %q, want 2025-12-25", got)
+	}
+	if _, err := parseInputDate("25/12/2025"); err == nil || !strings.Contains(err.Error(), "mm/dd/yyyy") {
+		t.Errorf("expected an error naming the configured format, got %v", err)
+	}
+}
+
+func TestConfigDefaultsApplied(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DefaultContext = "work"
+	cfg.WorkingHours = 7.5
+	useConfig(t, cfg)
+
+	opts, err := parseArgs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Context != "work" {
+		t.Errorf("context = %q, want work", opts.Context)
+	}
+
+	if d, _ := parseEstimate("1d"); d != 7*time.Hour+30*time.Minute {
+		t.Errorf("1d = %v, want 7h30m", d)
+	}
+}
+
+func TestTakeGlobalFlag(t *testing.T) {
+	path, rest, err := takeGlobalFlag([]string{"stats", "--config", "/tmp/gtd.toml", "--since", "01/01/2026"}, "--config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/tmp/gtd.toml" {
+		t.Errorf("path = %q", path)
+	}
+	if strings.Join(rest, " ") != "stats --since 01/01/2026" {
+		t.Errorf("rest = %v", rest)
+	}
+
+	if _, _, err := takeGlobalFlag([]string{"--config"}, "--config"); err == nil {
+		t.Error("expected an error for --config without a value")
+	}
+}
+
+func TestRunConfigShow(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DefaultContext = "work"
+	useConfig(t, cfg)
+	oldPath := configPath
+	configPath = filepath.Join(t.TempDir(), "config.toml")
+	t.Cleanup(func() { configPath = oldPath })
+	dbPath := filepath.Join(t.TempDir(), "tasks.db")
+	useDBFlag(t, dbPath)
+
+	out := captureOutput(t, func() error { return runConfig(nil, []string{"show"}) })
+
+	for _, want := range []string{"(not found, using defaults)", "# database: " + dbPath, `default_context = "work"`, `date_format = "dd/mm/yyyy"`, "working_hours = 8.0", "auto_rollover = false"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		t.Error("config show shouldn't create the database")
+	}
+
+	if err := runConfig(nil, nil); err == nil {
+		t.Error("expected usage error without show")
+	}
+}
+
+// useDBFlag sets --db for the rest of the test.
+func useDBFlag(t *testing.T, path string) {
+	t.Helper()
+	saved := dbFlag
+	dbFlag = path
+	t.Cleanup(func() { dbFlag = saved })
+}
+
+func TestRollOver(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-13", "Old open task", PriorityA, "1h", "default")
+	s.AddTask("2025-01-14", "Open task", PriorityA, "1h", "default")
+	s.AddTask("2025-01-14", "Finished task", PriorityB, "1h", "default")
+	tasks, _ := s.GetTasksForDate("2025-01-14", "default")
+	s.MarkComplete(tasks[1].ID)
+
+	from, n, err := rollOver(s, "2025-01-15", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != "2025-01-14" || n != 1 {
+		t.Errorf("got from=%q n=%d, want 2025-01-14 and 1", from, n)
+	}
+
+	// Running again carries nothing new
+	if _, n, _ := rollOver(s, "2025-01-15", "default"); n != 0 {
+		t.Errorf("second roll-over carried %d task(s)", n)
+	}
+	today, _ := s.GetTasksForDate("2025-01-15", "default")
+	if len(today) != 1 || !today[0].WasCarriedOver() {
+		t.Errorf("expected one carried task today, got %+v", today)
+	}
+}
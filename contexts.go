@@ -0,0 +1,445 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ContextSummary describes one context and how many tasks it holds.
+type ContextSummary struct {
+	Name     string
+	Total    int // every task row, including carried-over copies
+	Open     int // open tasks on their latest day (carried-forward originals aren't counted twice)
+	Done     int
+	LastDate string // most recent day with a task, yyyy-mm-dd
+	Archived bool
+}
+
+// ListContexts returns every context that has tasks, alphabetically, including archived ones.
+func (s *Store) ListContexts() ([]ContextSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT t.context,
+		       COUNT(*),
+		       SUM(`+openStatus+` AND t.id NOT IN (SELECT carried_from_id FROM tasks WHERE carried_from_id IS NOT NULL)),
+		       SUM(status = ?),
+		       MAX(date),
+		       COALESCE(MAX(c.archived), 0)
+		FROM tasks t LEFT JOIN contexts c ON c.name = t.context
+		GROUP BY t.context
+		ORDER BY t.context`, StatusDone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contexts []ContextSummary
+	for rows.Next() {
+		var c ContextSummary
+		if err := rows.Scan(&c.Name, &c.Total, &c.Open, &c.Done, &c.LastDate, &c.Archived); err != nil {
+			return nil, err
+		}
+		contexts = append(contexts, c)
+	}
+	return contexts, rows.Err()
+}
+
+// countContextTasks returns how many tasks belong to the named context.
+func (s *Store) countContextTasks(name string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM tasks WHERE context = ?`, name).Scan(&n)
+	return n, err
+}
+
+// contextExists reports whether any task or inbox item belongs to the named context.
+func (s *Store) contextExists(name string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM tasks WHERE context = ?)
+		    OR EXISTS (SELECT 1 FROM inbox WHERE context = ?)`, name, name).Scan(&exists)
+	return exists, err
+}
+
+// RenameContext gives a context a new name. It refuses to overwrite an existing context;
+// use MergeContext to combine two.
+func (s *Store) RenameContext(from, to string) error {
+	if exists, err := s.contextExists(to); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("context %q already exists (use merge to combine them)", to)
+	}
+	return s.moveContext(from, to)
+}
+
+// MergeContext moves every task and inbox item from one context into another existing one.
+func (s *Store) MergeContext(from, to string) error {
+	if exists, err := s.contextExists(to); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("context %q doesn't exist (use rename instead)", to)
+	}
+	return s.moveContext(from, to)
+}
+
+func (s *Store) moveContext(from, to string) error {
+	if from == to {
+		return fmt.Errorf("source and destination are both %q", from)
+	}
+	if exists, err := s.contextExists(from); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("no such context %q", from)
+	}
+
+	var moved []Task
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		if moved, err = tasksInContext(tx, from); err != nil {
+			return err
+		}
+		now := s.timestamp()
+		_, err = tx.Exec(`
+			INSERT INTO task_events (task_id, event, detail, created_at)
+			SELECT id, 'moved', ?, ? FROM tasks WHERE context = ?`,
+			fmt.Sprintf("context %s → %s", from, to), now, from)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE tasks SET context = ?, updated_at = ? WHERE context = ?`, to, now, from); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE inbox SET context = ? WHERE context = ?`, to, from); err != nil {
+			return err
+		}
+		// The destination keeps its own settings; the source's go with it only on a rename.
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO contexts (name, archived) SELECT ?, archived FROM contexts WHERE name = ?`, to, from); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM contexts WHERE name = ?`, from)
+		return err
+	})
+	if err == nil {
+		for _, t := range moved {
+			s.fireTask("task.moved", t.ID)
+		}
+	}
+	return err
+}
+
+// tasksInContext returns every task in a context, for firing hooks once a change to the
+// whole context commits.
+func tasksInContext(tx *sql.Tx, name string) ([]Task, error) {
+	rows, err := tx.Query(`SELECT `+taskColumns+` FROM tasks WHERE context = ? ORDER BY date, priority, id`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTasks(rows)
+}
+
+// SetContextArchived hides (or un-hides) a context from listings and the context switcher.
+// Its tasks are left untouched apart from an "archived" or "unarchived" event each, logged
+// only when the setting actually changes.
+func (s *Store) SetContextArchived(name string, archived bool) error {
+	if exists, err := s.contextExists(name); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("no such context %q", name)
+	}
+	return s.inTx(func(tx *sql.Tx) error {
+		var was bool
+		err := tx.QueryRow(`SELECT archived FROM contexts WHERE name = ?`, name).Scan(&was)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if was == archived {
+			return nil
+		}
+		_, err = tx.Exec(`
+			INSERT INTO contexts (name, archived) VALUES (?, ?)
+			ON CONFLICT(name) DO UPDATE SET archived = excluded.archived`, name, archived)
+		if err != nil {
+			return err
+		}
+		event := "archived"
+		if !archived {
+			event = "unarchived"
+		}
+		_, err = tx.Exec(`
+			INSERT INTO task_events (task_id, event, detail, created_at)
+			SELECT id, ?, ?, ? FROM tasks WHERE context = ?`,
+			event, "context "+name, s.timestamp(), name)
+		return err
+	})
+}
+
+// DeleteContext removes a context with every task and inbox item in it, returning how many tasks
+// were deleted.
+func (s *Store) DeleteContext(name string) (int, error) {
+	if err := s.snapshotBefore("delete"); err != nil {
+		return 0, err
+	}
+	var deleted []Task
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		if deleted, err = tasksInContext(tx, name); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO task_events (task_id, event, detail, created_at)
+			SELECT id, 'deleted', description, ? FROM tasks WHERE context = ?`, s.timestamp(), name)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM tasks WHERE context = ?`, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM inbox WHERE context = ?`, name); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM contexts WHERE name = ?`, name)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range deleted {
+		s.fireTaskValue("task.deleted", t)
+	}
+	return len(deleted), nil
+}
+
+// checkContext returns a warning when name isn't an active context: either it has never
+// been used (often a typo, in which case the closest known name is suggested) or it has
+// been archived. It returns "" for known contexts and for the default context.
+func (s *Store) checkContext(name string) (string, error) {
+	if name == config.DefaultContext {
+		return "", nil
+	}
+
+	contexts, err := s.ListContexts()
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, c := range contexts {
+		if c.Name == name {
+			if c.Archived {
+				return fmt.Sprintf("Context %q is archived.", name), nil
+			}
+			return "", nil
+		}
+		if !c.Archived {
+			names = append(names, c.Name)
+		}
+	}
+
+	// A context with only inbox items so far isn't in the listing, but it does exist
+	if exists, err := s.contextExists(name); err != nil {
+		return "", err
+	} else if exists {
+		var archived bool
+		err := s.db.QueryRow(`SELECT archived FROM contexts WHERE name = ?`, name).Scan(&archived)
+		if err != nil && err != sql.ErrNoRows {
+			return "", err
+		}
+		if archived {
+			return fmt.Sprintf("Context %q is archived.", name), nil
+		}
+		return "", nil
+	}
+
+	warning := fmt.Sprintf("Context %q doesn't exist yet.", name)
+	if suggestion := closestName(name, names); suggestion != "" {
+		warning += fmt.Sprintf(" Did you mean %q?", suggestion)
+	}
+	return warning, nil
+}
+
+// closestName returns the candidate within a small edit distance of name, or "" if none is
+// close enough to be a likely typo.
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", 0
+	limit := max(1, len(name)/3)
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		if d <= limit && (best == "" || d < bestDist) {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance between a and b: Levenshtein
+// distance, except that swapping two adjacent letters ("wrok") counts as one edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// GetTasksForDateAllContexts loads a day's tasks from every context, grouped by context.
+func (s *Store) GetTasksForDateAllContexts(date string) ([]Task, error) {
+	rows, err := s.db.Query(
+		`SELECT `+taskColumns+`
+		 FROM tasks WHERE date = ? ORDER BY context, priority, id`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// runContexts implements "gtd contexts [--archived]".
+func runContexts(store *Store, args []string) error {
+	showArchived := false
+	for _, arg := range args {
+		if arg != "--archived" {
+			return fmt.Errorf("unknown argument %q\n%s", arg, commandUsage("contexts"))
+		}
+		showArchived = true
+	}
+
+	contexts, err := store.ListContexts()
+	if err != nil {
+		return err
+	}
+	if !showArchived {
+		contexts = activeContexts(contexts)
+	}
+	if len(contexts) == 0 {
+		fmt.Println("No contexts yet.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Context\tTasks\tOpen\tDone\tLast used")
+	for _, c := range contexts {
+		last, _ := time.Parse("2006-01-02", c.LastDate)
+		name := c.Name
+		if c.Archived {
+			name += " (archived)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", name, c.Total, c.Open, c.Done, last.Format(config.dateLayout()))
+	}
+	return w.Flush()
+}
+
+func activeContexts(contexts []ContextSummary) []ContextSummary {
+	var active []ContextSummary
+	for _, c := range contexts {
+		if !c.Archived {
+			active = append(active, c)
+		}
+	}
+	return active
+}
+
+// runContext implements "gtd context rename|merge|archive|unarchive|delete".
+func runContext(store *Store, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing action\n%s", commandUsage("context"))
+	}
+
+	action, rest := args[0], args[1:]
+	confirmed := false
+	if action == "delete" && len(rest) > 0 && rest[len(rest)-1] == "--yes" {
+		confirmed = true
+		rest = rest[:len(rest)-1]
+	}
+	wantArgs := map[string]int{"rename": 2, "merge": 2, "archive": 1, "unarchive": 1, "delete": 1}[action]
+	if wantArgs == 0 || len(rest) != wantArgs {
+		return fmt.Errorf("bad arguments for %q\n%s", action, commandUsage("context"))
+	}
+
+	switch action {
+	case "rename":
+		if err := store.RenameContext(rest[0], rest[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Renamed context %q to %q.\n", rest[0], rest[1])
+	case "merge":
+		if err := store.MergeContext(rest[0], rest[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Merged context %q into %q.\n", rest[0], rest[1])
+	case "archive", "unarchive":
+		if err := store.SetContextArchived(rest[0], action == "archive"); err != nil {
+			return err
+		}
+		fmt.Printf("Context %q %sd.\n", rest[0], action)
+	case "delete":
+		if exists, err := store.contextExists(rest[0]); err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("no such context %q", rest[0])
+		}
+		count, err := store.countContextTasks(rest[0])
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("this would delete %d task(s) in %q; re-run with --yes to confirm", count, rest[0])
+		}
+		n, err := store.DeleteContext(rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted context %q and its %d task(s).\n", rest[0], n)
+	}
+	return nil
+}
+
+// printTasksAllContexts is the --all-contexts version of printTasks.
+func printTasksAllContexts(store *Store, date string, filter query) error {
+	tasks, err := store.GetTasksForDateAllContexts(date)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(formatHeading(date) + " · all contexts")
+	fmt.Println()
+
+	if len(tasks) == 0 {
+		fmt.Println("No tasks for this day.")
+		return nil
+	}
+
+	today := time.Now().Format("2006-01-02")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tContext\tTask\tPriority\tTime\tDue\tStatus")
+	for i, t := range tasks {
+		if !filter.Match(t) {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, t.Context, t.DisplayDescription(), t.Priority, t.TimeEstimate, t.DueDisplay(today), t.Status.PrintLabel())
+	}
+	w.Flush()
+
+	fmt.Println("\n" + filteredSummary(tasks, filter))
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListContexts(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Work A", PriorityA, "1h", "work")
+	s.AddTask("2025-01-15", "Work B", PriorityB, "1h", "work")
+	s.AddTask("2025-01-16", "Home", PriorityB, "1h", "home")
+
+	work, _ := s.GetTasksForDate("2025-01-15", "work")
+	s.MarkComplete(work[0].ID)
+	// Carrying the open task shouldn't count it as open twice
+	s.CarryOverTasks(work[1:], "2025-01-16", "work")
+
+	contexts, err := s.ListContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contexts) != 2 {
+		t.Fatalf("expected 2 contexts, got %d", len(contexts))
+	}
+
+	home, w := contexts[0], contexts[1]
+	if home.Name != "home" || w.Name != "work" {
+		t.Fatalf("expected contexts sorted by name, got %q, %q", home.Name, w.Name)
+	}
+	if w.Total != 3 || w.Open != 1 || w.Done != 1 || w.LastDate != "2025-01-16" {
+		t.Errorf("work = %+v, want 3 total, 1 open, 1 done, last 2025-01-16", w)
+	}
+	if home.Total != 1 || home.Open != 1 {
+		t.Errorf("home = %+v, want 1 total, 1 open", home)
+	}
+}
+
+func TestGetTasksForDateAllContexts(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Work task", PriorityB, "1h", "work")
+	s.AddTask("2025-01-15", "Default task", PriorityA, "1h", "default")
+	s.AddTask("2025-01-16", "Tomorrow", PriorityA, "1h", "work")
+
+	tasks, err := s.GetTasksForDateAllContexts("2025-01-15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Context != "default" || tasks[1].Context != "work" {
+		t.Errorf("expected tasks grouped by context, got %q then %q", tasks[0].Context, tasks[1].Context)
+	}
+}
+
+func TestRunContexts(t *testing.T) {
+	s := newTestStore(t)
+
+	output := captureOutput(t, func() error { return runContexts(s, nil) })
+	if !strings.Contains(output, "No contexts yet.") {
+		t.Errorf("expected empty message, got:\n%s", output)
+	}
+
+	s.AddTask("2025-01-15", "Work task", PriorityB, "1h", "work")
+	output = captureOutput(t, func() error { return runContexts(s, nil) })
+	for _, want := range []string{"Context", "work", "15/01/2025"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestPrintTasksAllContexts(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Work task", PriorityB, "1h", "work")
+	s.AddTask("2025-01-15", "Home task", PriorityA, "1h", "home")
+
+	output := captureOutput(t, func() error { return printTasksAllContexts(s, "2025-01-15", query{}) })
+	for _, want := range []string{"all contexts", "Context", "work", "home", "0/2 tasks completed"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestRenameContext(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Typo task", PriorityA, "1h", "wrok")
+	s.AddTask("2025-01-15", "Real task", PriorityA, "1h", "work")
+
+	if err := s.RenameContext("wrok", "work"); err == nil {
+		t.Error("expected rename onto an existing context to fail")
+	}
+	if err := s.RenameContext("nope", "other"); err == nil {
+		t.Error("expected rename of a missing context to fail")
+	}
+	if err := s.RenameContext("wrok", "typos"); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, _ := s.GetTasksForDate("2025-01-15", "typos")
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task in renamed context, got %d", len(tasks))
+	}
+	events, _ := s.GetTaskEvents(tasks[0].ID)
+	if last := events[len(events)-1]; last.Event != "moved" || last.Detail != "context wrok → typos" {
+		t.Errorf("expected moved event, got %+v", last)
+	}
+}
+
+func TestMergeContext(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Typo task", PriorityA, "1h", "wrok")
+	s.AddTask("2025-01-15", "Real task", PriorityA, "1h", "work")
+
+	if err := s.MergeContext("wrok", "missing"); err == nil {
+		t.Error("expected merge into a missing context to fail")
+	}
+	if err := s.MergeContext("wrok", "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, _ := s.GetTasksForDate("2025-01-15", "work")
+	if len(tasks) != 2 {
+		t.Errorf("expected 2 tasks after merge, got %d", len(tasks))
+	}
+	if exists, _ := s.contextExists("wrok"); exists {
+		t.Error("merged context should be gone")
+	}
+}
+
+func TestArchiveContext(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Old project", PriorityA, "1h", "project")
+	if err := s.SetContextArchived("project", true); err != nil {
+		t.Fatal(err)
+	}
+
+	contexts, _ := s.ListContexts()
+	if len(contexts) != 1 || !contexts[0].Archived {
+		t.Fatalf("expected archived context, got %+v", contexts)
+	}
+	if len(activeContexts(contexts)) != 0 {
+		t.Error("archived context should not be active")
+	}
+	// Tasks are untouched
+	if tasks, _ := s.GetTasksForDate("2025-01-15", "project"); len(tasks) != 1 {
+		t.Error("archiving should keep the context's tasks")
+	}
+
+	// Archived flag follows a rename
+	s.RenameContext("project", "old-project")
+	contexts, _ = s.ListContexts()
+	if !contexts[0].Archived {
+		t.Error("renamed context should stay archived")
+	}
+
+	s.SetContextArchived("old-project", false)
+	s.SetContextArchived("old-project", false) // already unarchived: nothing to log
+	contexts, _ = s.ListContexts()
+	if contexts[0].Archived {
+		t.Error("expected context to be unarchived")
+	}
+
+	tasks, _ := s.GetTasksForDate("2025-01-15", "old-project")
+	events, _ := s.GetTaskEvents(tasks[0].ID)
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Event+" "+e.Detail)
+	}
+	want := "created  archived context project moved context project → old-project unarchived context old-project"
+	if strings.Join(got, " ") != want {
+		t.Errorf("events = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestDeleteContext(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-01-15", "Throwaway", PriorityA, "1h", "demo")
+	s.AddTask("2025-01-16", "Throwaway 2", PriorityA, "1h", "demo")
+	s.AddTask("2025-01-15", "Keep me", PriorityA, "1h", "work")
+
+	n, err := s.DeleteContext("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 tasks deleted, got %d", n)
+	}
+	contexts, _ := s.ListContexts()
+	if len(contexts) != 1 || contexts[0].Name != "work" {
+		t.Errorf("expected only work to remain, got %+v", contexts)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	s := newTestStore(t)
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: "Wrong list", Priority: PriorityA, TimeEstimate: "1h", Context: "home"})
+	if err := s.MoveTask(id, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	task, _ := s.GetTask(id)
+	if task.Context != "work" {
+		t.Errorf("context = %q, want work", task.Context)
+	}
+}
+
+func TestRunContextDeleteRequiresYes(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-01-15", "Throwaway", PriorityA, "1h", "demo")
+
+	if err := runContext(s, []string{"delete", "demo"}); err == nil {
+		t.Error("expected delete without --yes to fail")
+	}
+	if exists, _ := s.contextExists("demo"); !exists {
+		t.Fatal("context should not be deleted without --yes")
+	}
+
+	captureOutput(t, func() error { return runContext(s, []string{"delete", "demo", "--yes"}) })
+	if exists, _ := s.contextExists("demo"); exists {
+		t.Error("context should be deleted with --yes")
+	}
+}
+
+func TestRunContextBadArgs(t *testing.T) {
+	s := newTestStore(t)
+	for _, args := range [][]string{nil, {"rename", "only-one"}, {"explode", "x"}} {
+		if err := runContext(s, args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestCheckContext(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-01-15", "Task", PriorityA, "1h", "work")
+	s.AddTask("2025-01-15", "Task", PriorityA, "1h", "old")
+	s.SetContextArchived("old", true)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"default", ""},
+		{"work", ""},
+		{"wrok", `Context "wrok" doesn't exist yet. Did you mean "work"?`},
+		{"personal", `Context "personal" doesn't exist yet.`},
+		{"old", `Context "old" is archived.`},
+	}
+	for _, tt := range tests {
+		got, err := s.checkContext(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("checkContext(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClosestName(t *testing.T) {
+	candidates := []string{"work", "personal", "side-project"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"wrok", "work"},
+		{"Work", "work"},
+		{"persnal", "personal"},
+		{"home", ""},
+		{"x", ""},
+	}
+	for _, tt := range tests {
+		if got := closestName(tt.name, candidates); got != tt.want {
+			t.Errorf("closestName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// exportFormats are the formats gtd export and gtd import understand, by --format name.
+var exportFormats = map[string]struct {
+	ext   string // file extension, for guessing the format of an import
+	write func(io.Writer, []Task) error
+	read  func(io.Reader) ([]Task, error)
+}{
+	"ics":     {".ics", writeICS, readICS},
+	"todotxt": {".txt", writeTodoTxt, readTodoTxt},
+}
+
+func exportFormatNames() []string {
+	var names []string
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetTasksInRange returns every task planned between from and to inclusive (yyyy-mm-dd), in
+// date order. An empty bound is open-ended, and an empty context means every context.
+func (s *Store) GetTasksInRange(from, to, context string) ([]Task, error) {
+	where := []string{"1 = 1"}
+	var args []any
+	if from != "" {
+		where = append(where, "date >= ?")
+		args = append(args, from)
+	}
+	if to != "" {
+		where = append(where, "date <= ?")
+		args = append(args, to)
+	}
+	if context != "" {
+		where = append(where, "context = ?")
+		args = append(args, context)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY date, context, priority, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// ImportTasks adds tasks read from a file, skipping any whose UID is already in the database
+// (so importing the same file twice adds nothing). New tasks are added as CreateTask adds them,
+// with source recorded in their created event.
+func (s *Store) ImportTasks(tasks []Task, source string) (added, skipped int, err error) {
+	if len(tasks) == 0 {
+		return 0, 0, nil
+	}
+	if err := s.snapshotBefore("import"); err != nil {
+		return 0, 0, err
+	}
+	var ids []int64
+	err = s.inTx(func(tx *sql.Tx) error {
+		for _, t := range tasks {
+			if t.UID != "" {
+				var exists int
+				if err := tx.QueryRow(`SELECT COUNT(*) FROM tasks WHERE uid = ?`, t.UID).Scan(&exists); err != nil {
+					return err
+				}
+				if exists > 0 {
+					skipped++
+					continue
+				}
+			}
+
+			id, err := s.createTask(tx, t, "imported from "+source)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range ids {
+		s.fireTask("task.added", id)
+	}
+	return len(ids), skipped, nil
+}
+
+// runExport implements "gtd export --format <f> [--context x | --all-contexts] [--from d] [--to d]".
+func runExport(store *Store, args []string) error {
+	usage := commandUsage("export")
+
+	var format, from, to string
+	context := config.DefaultContext
+	allContexts := false
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--all-contexts" {
+			allContexts = true
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--format"); ok {
+			if err != nil {
+				return err
+			}
+			format, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--from"); ok {
+			if err != nil {
+				return err
+			}
+			date, err := parseInputDate(value)
+			if err != nil {
+				return err
+			}
+			from, i = date, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--to"); ok {
+			if err != nil {
+				return err
+			}
+			date, err := parseInputDate(value)
+			if err != nil {
+				return err
+			}
+			to, i = date, next
+			continue
+		}
+		return fmt.Errorf("unknown argument %q\n%s", args[i], usage)
+	}
+
+	f, ok := exportFormats[format]
+	if !ok {
+		if format == "" {
+			return fmt.Errorf("%s", usage)
+		}
+		return fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(exportFormatNames(), ", "))
+	}
+	if allContexts {
+		context = ""
+	}
+
+	tasks, err := store.GetTasksInRange(from, to, context)
+	if err != nil {
+		return err
+	}
+	return f.write(os.Stdout, tasks)
+}
+
+// runImport implements "gtd import <file> [--format <f>] [--context x]". The format is guessed
+// from the file extension when --format isn't given; "-" reads standard input.
+func runImport(store *Store, args []string) error {
+	usage := commandUsage("import")
+
+	var path, format, context string
+	for i := 0; i < len(args); i++ {
+		if value, next, ok, err := takeFlag(args, i, "--format"); ok {
+			if err != nil {
+				return err
+			}
+			format, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		if path != "" || strings.HasPrefix(args[i], "--") {
+			return fmt.Errorf("unknown argument %q\n%s", args[i], usage)
+		}
+		path = args[i]
+	}
+	if path == "" {
+		return fmt.Errorf("%s", usage)
+	}
+
+	if format == "" {
+		for name, f := range exportFormats {
+			if strings.EqualFold(filepath.Ext(path), f.ext) {
+				format = name
+			}
+		}
+		if format == "" {
+			return fmt.Errorf("can't tell the format of %s; use --format (available: %s)", path, strings.Join(exportFormatNames(), ", "))
+		}
+	}
+	f, ok := exportFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(exportFormatNames(), ", "))
+	}
+
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	read, err := f.read(in)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var tasks []Task
+	untitled := 0
+	for _, t := range read {
+		if t.Description == "" {
+			untitled++
+			continue
+		}
+		switch {
+		case context != "":
+			t.Context = context
+		case t.Context == "":
+			t.Context = config.DefaultContext
+		}
+		tasks = append(tasks, t)
+	}
+
+	added, skipped, err := store.ImportTasks(tasks, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Imported %d task(s) from %s", added, path)
+	if skipped > 0 {
+		msg += fmt.Sprintf(", skipped %d already imported", skipped)
+	}
+	if untitled > 0 {
+		msg += fmt.Sprintf(", skipped %d without a name", untitled)
+	}
+	fmt.Println(msg + ".")
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetTasksInRange(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "First", PriorityB, "1h", "work")
+	s.AddTask("2025-06-02", "Second", PriorityA, "1h", "work")
+	s.AddTask("2025-06-02", "Home", PriorityA, "1h", "home")
+	s.AddTask("2025-06-03", "Third", PriorityC, "1h", "work")
+
+	tests := []struct {
+		from, to, context string
+		want              []string
+	}{
+		{"", "", "work", []string{"First", "Second", "Third"}},
+		{"2025-06-02", "", "work", []string{"Second", "Third"}},
+		{"", "2025-06-02", "", []string{"First", "Home", "Second"}},
+		{"2025-06-02", "2025-06-02", "home", []string{"Home"}},
+	}
+	for _, tt := range tests {
+		tasks, err := s.GetTasksInRange(tt.from, tt.to, tt.context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, task := range tasks {
+			got = append(got, task.Description)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("GetTasksInRange(%q, %q, %q) = %v, want %v", tt.from, tt.to, tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestExportImportICS(t *testing.T) {
+	src := newTestStore(t)
+	src.AddTask("2025-06-01", "Patch web servers", PriorityA, "2h", "default")
+	src.AddTask("2025-06-02", "Order toner", PriorityC, "5m", "default")
+	src.AddTask("2025-06-02", "Fix the fence", PriorityB, "1d", "home")
+
+	output := captureOutput(t, func() error { return runExport(src, []string{"--format", "ics", "--from", "02/06/2025"}) })
+	if strings.Count(output, "BEGIN:VTODO") != 1 || !strings.Contains(output, "SUMMARY:Order toner") {
+		t.Fatalf("expected only the default context from 2 June, got:\n%s", output)
+	}
+	output = captureOutput(t, func() error { return runExport(src, []string{"--format=ics", "--all-contexts"}) })
+	if strings.Count(output, "BEGIN:VTODO") != 3 {
+		t.Fatalf("expected every task, got:\n%s", output)
+	}
+
+	path := filepath.Join(t.TempDir(), "tasks.ics")
+	if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTestStore(t)
+	msg := captureOutput(t, func() error { return runImport(dst, []string{path}) })
+	if !strings.Contains(msg, "Imported 3 task(s)") {
+		t.Errorf("unexpected import message: %q", msg)
+	}
+	msg = captureOutput(t, func() error { return runImport(dst, []string{path}) })
+	if !strings.Contains(msg, "Imported 0 task(s)") || !strings.Contains(msg, "skipped 3 already imported") {
+		t.Errorf("expected a second import to add nothing, got %q", msg)
+	}
+
+	home, _ := dst.GetTasksForDate("2025-06-02", "home")
+	if len(home) != 1 || home[0].TimeEstimate != "1d" || home[0].Priority != PriorityB {
+		t.Errorf("expected the home task with its estimate and priority, got %+v", home)
+	}
+	events, _ := dst.GetTaskEvents(home[0].ID)
+	if len(events) != 1 || events[0].Detail != "imported from tasks.ics" {
+		t.Errorf("expected an import event, got %+v", events)
+	}
+
+	// --context puts everything into one context
+	other := newTestStore(t)
+	captureOutput(t, func() error { return runImport(other, []string{path, "--context", "inbox"}) })
+	if tasks, _ := other.GetTasksInRange("", "", "inbox"); len(tasks) != 3 {
+		t.Errorf("expected 3 tasks in inbox, got %d", len(tasks))
+	}
+}
+
+func TestExportImportErrors(t *testing.T) {
+	s := newTestStore(t)
+	noExt := filepath.Join(t.TempDir(), "tasks.dat")
+	os.WriteFile(noExt, []byte("BEGIN:VCALENDAR\n"), 0o644)
+
+	for _, args := range [][]string{nil, {"--format", "csv"}, {"--format", "ics", "--bogus"}, {"--format", "ics", "--from", "June"}} {
+		if err := runExport(s, args); err == nil {
+			t.Errorf("runExport(%v): expected an error", args)
+		}
+	}
+	for _, args := range [][]string{nil, {noExt}, {noExt, "--format", "csv"}, {"a.ics", "b.ics"}, {"missing.ics"}} {
+		if err := runImport(s, args); err == nil {
+			t.Errorf("runImport(%v): expected an error", args)
+		}
+	}
+}
+
+func TestMigrateAssignsUIDs(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Old task", PriorityB, "1h", "default")
+	s.AddTask("2025-06-01", "Another", PriorityB, "1h", "default")
+
+	// Simulate rows from before the uid column
+	if _, err := s.db.Exec(`DROP INDEX tasks_uid; UPDATE tasks SET uid = ''`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, _ := s.GetTasksForDate("2025-06-01", "default")
+	if len(tasks) != 2 || tasks[0].UID == "" || tasks[0].UID == tasks[1].UID {
+		t.Errorf("expected distinct UIDs, got %+v", tasks)
+	}
+}
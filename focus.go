@@ -0,0 +1,269 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RecordPomodoro counts a finished focus interval of the given length against a task.
+func (s *Store) RecordPomodoro(id int64, length time.Duration) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE tasks SET pomodoros = pomodoros + 1, updated_at = ? WHERE id = ?`, s.timestamp(), id)
+		if err != nil {
+			return err
+		}
+		return s.logEvent(tx, id, "pomodoro", formatDuration(length))
+	})
+}
+
+// RecordBreak logs a break taken between focus intervals on a task.
+func (s *Store) RecordBreak(id int64, length time.Duration) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		return s.logEvent(tx, id, "break", formatDuration(length))
+	})
+}
+
+// --- Focus mode ---
+
+type focusPhase int
+
+const (
+	focusWork      focusPhase = iota // counting down a pomodoro
+	focusWorkDone                    // pomodoro finished: done, or break and continue?
+	focusBreak                       // counting down a break
+	focusBreakDone                   // break finished: start the next pomodoro?
+)
+
+// focusTickMsg drives the countdown once a second. Ticks from an earlier session (before a
+// pause, or a previous focus) are ignored, so only one tick chain is ever running.
+type focusTickMsg struct {
+	session int
+	at      time.Time
+}
+
+// ringBell sounds the terminal bell. It writes to stderr so it can't land in the middle of a
+// frame being drawn on stdout.
+var ringBell = func() { fmt.Fprint(os.Stderr, "\a") }
+
+func bell() tea.Msg {
+	ringBell()
+	return nil
+}
+
+// enterFocusMode starts a pomodoro on the selected task, marking it in progress.
+func (m *model) enterFocusMode() (tea.Model, tea.Cmd) {
+	task, ok := m.selectedTask()
+	if !ok {
+		m.status = "No tasks."
+		return m, nil
+	}
+
+	if task.Status.IsClosed() {
+		m.status = "That task is already finished."
+		return m, nil
+	}
+	if task.Status != StatusInProgress {
+		if err := m.store.SetStatus(task.ID, StatusInProgress); err != nil {
+			m.status = "Error starting task."
+			return m, nil
+		}
+		task.Status = StatusInProgress
+	}
+
+	m.focusTask = task
+	m.focusCount = 0
+	m.mode = modeFocus
+	return m, m.startFocusPhase(focusWork, config.pomodoro())
+}
+
+// startFocusPhase starts counting down a pomodoro or a break.
+func (m *model) startFocusPhase(phase focusPhase, length time.Duration) tea.Cmd {
+	m.focusPhase = phase
+	m.focusStarted = time.Now()
+	m.focusEnd = m.focusStarted.Add(length)
+	m.focusPaused = false
+	return m.focusTick()
+}
+
+func (m *model) focusTick() tea.Cmd {
+	m.focusSession++
+	session := m.focusSession
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return focusTickMsg{session: session, at: t} })
+}
+
+// focusTicked ends the running phase once its time is up, or waits for the next tick.
+func (m *model) focusTicked(msg focusTickMsg) (tea.Model, tea.Cmd) {
+	if m.mode != modeFocus || msg.session != m.focusSession || m.focusPaused {
+		return m, nil
+	}
+	if msg.at.Before(m.focusEnd) {
+		return m, m.focusTick()
+	}
+
+	switch m.focusPhase {
+	case focusWork:
+		if err := m.store.RecordPomodoro(m.focusTask.ID, config.pomodoro()); err != nil {
+			m.status = "Error recording pomodoro."
+		}
+		m.focusCount++
+		m.focusTask.Pomodoros++
+		m.focusPhase = focusWorkDone
+	case focusBreak:
+		if err := m.store.RecordBreak(m.focusTask.ID, config.breakLength()); err != nil {
+			m.status = "Error recording break."
+		}
+		m.focusPhase = focusBreakDone
+	}
+	return m, bell
+}
+
+func (m *model) updateFocus(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		m.status = "Focus stopped."
+		if m.focusCount > 0 {
+			m.status = fmt.Sprintf("Focus stopped after %d pomodoro(s).", m.focusCount)
+		}
+		m.mode = modeTable
+		m.refreshTasks()
+		return m, nil
+	}
+
+	switch m.focusPhase {
+	case focusWork, focusBreak:
+		switch keyMsg.String() {
+		case " ":
+			if m.focusPaused {
+				m.focusEnd = time.Now().Add(m.focusLeft)
+				m.focusPaused = false
+				return m, m.focusTick()
+			}
+			m.focusLeft = time.Until(m.focusEnd)
+			m.focusPaused = true
+		case "s":
+			if m.focusPhase == focusBreak {
+				// Skipping the break still records the time actually taken
+				if err := m.store.RecordBreak(m.focusTask.ID, time.Since(m.focusStarted)); err != nil {
+					m.status = "Error recording break."
+				}
+				return m, m.startFocusPhase(focusWork, config.pomodoro())
+			}
+		}
+
+	case focusWorkDone, focusBreakDone:
+		switch keyMsg.String() {
+		case "d":
+			if err := m.store.MarkComplete(m.focusTask.ID); err != nil {
+				m.status = "Error updating task."
+			} else {
+				m.status = fmt.Sprintf("Task marked as done after %d pomodoro(s).", m.focusTask.Pomodoros)
+			}
+			m.mode = modeTable
+			m.refreshTasks()
+		case "c", "enter":
+			if m.focusPhase == focusWorkDone {
+				return m, m.startFocusPhase(focusBreak, config.breakLength())
+			}
+			return m, m.startFocusPhase(focusWork, config.pomodoro())
+		}
+	}
+	return m, nil
+}
+
+// focusRemaining is the time left in the running phase, rounded up to whole seconds.
+func (m *model) focusRemaining() time.Duration {
+	left := time.Until(m.focusEnd)
+	switch {
+	case m.focusPhase == focusWorkDone || m.focusPhase == focusBreakDone:
+		return 0
+	case m.focusPaused:
+		left = m.focusLeft
+	}
+	return time.Duration(math.Ceil(max(left, 0).Seconds())) * time.Second
+}
+
+// focusView is the full-screen countdown.
+func (m *model) focusView() string {
+	var heading, help string
+	color := theme.Accent
+	switch m.focusPhase {
+	case focusWork:
+		heading = fmt.Sprintf("Pomodoro %d", m.focusTask.Pomodoros+1)
+		help = "space pause · esc stop"
+	case focusWorkDone:
+		heading = "Pomodoro done — time for a break"
+		help = "c/↵ break, then continue · d mark done · esc stop"
+	case focusBreak:
+		heading = "Break"
+		color = theme.Success
+		help = "space pause · s skip break · esc stop"
+	case focusBreakDone:
+		heading = "Break over"
+		color = theme.Success
+		help = "c/↵ next pomodoro · d mark done · esc stop"
+	}
+	if m.focusPaused {
+		heading += " (paused)"
+	}
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(heading))
+	s.WriteString("\n\n")
+	s.WriteString(m.focusTask.Description)
+	s.WriteString("\n\n")
+	s.WriteString(lipgloss.NewStyle().Foreground(color).Render(bigClock(m.focusRemaining())))
+	s.WriteString("\n\n")
+	if m.status != "" {
+		s.WriteString(statusStyle.Render(m.status))
+		s.WriteString("\n\n")
+	}
+	s.WriteString(helpStyle.Render(help))
+
+	content := lipgloss.NewStyle().Align(lipgloss.Center).Render(s.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// bigDigits are 3×5 block glyphs for the countdown, indexed by character.
+var bigDigits = map[rune][5]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {"  █", "  █", "  █", "  █", "  █"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", "███", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", "  █", "  █", "  █"},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+	':': {" ", "█", " ", "█", " "},
+}
+
+// bigClock renders d as mm:ss in block digits.
+func bigClock(d time.Duration) string {
+	secs := int(d / time.Second)
+	text := fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+
+	var lines [5][]string
+	for _, r := range text {
+		for i, row := range bigDigits[r] {
+			lines[i] = append(lines[i], row)
+		}
+	}
+	rows := make([]string, len(lines))
+	for i, parts := range lines {
+		rows[i] = strings.Join(parts, " ")
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRecordPomodoro(t *testing.T) {
+	s := newTestStore(t)
+	id, _ := s.CreateTask(Task{Date: "2025-06-01", Description: "Write runbook", Priority: PriorityA, TimeEstimate: "2h", Context: "default"})
+
+	s.RecordPomodoro(id, 25*time.Minute)
+	s.RecordBreak(id, 5*time.Minute)
+	s.RecordPomodoro(id, 25*time.Minute)
+
+	task, err := s.GetTask(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Pomodoros != 2 {
+		t.Errorf("pomodoros = %d, want 2", task.Pomodoros)
+	}
+
+	events, _ := s.GetTaskEvents(id)
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Event+" "+e.Detail)
+	}
+	want := "created |pomodoro 25m|break 5m|pomodoro 25m"
+	if strings.Join(got, "|") != want {
+		t.Errorf("events = %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	// The count follows the task when it's carried over
+	s.CarryOverTasks([]Task{task}, "2025-06-02", "default")
+	carried, _ := s.GetTasksForDate("2025-06-02", "default")
+	if len(carried) != 1 || carried[0].Pomodoros != 2 {
+		t.Errorf("expected the carried copy to keep 2 pomodoros, got %+v", carried)
+	}
+}
+
+// finishFocusPhase delivers the tick that ends the running pomodoro or break.
+func finishFocusPhase(m *model) tea.Cmd {
+	_, cmd := m.Update(focusTickMsg{session: m.focusSession, at: m.focusEnd})
+	return cmd
+}
+
+func TestFocusMode(t *testing.T) {
+	rung := 0
+	saved := ringBell
+	ringBell = func() { rung++ }
+	t.Cleanup(func() { ringBell = saved })
+
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Write runbook", PriorityA, "2h", "default")
+	m := newModel(s, "2025-06-01", "default", false)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if m.mode != modeFocus || m.focusPhase != focusWork || cmd == nil {
+		t.Fatalf("expected p to start a pomodoro, mode %v phase %v", m.mode, m.focusPhase)
+	}
+	if got := m.focusEnd.Sub(m.focusStarted); got != 25*time.Minute {
+		t.Errorf("pomodoro length = %v, want 25m", got)
+	}
+	if task, _ := s.GetTask(m.focusTask.ID); task.Status != StatusInProgress {
+		t.Errorf("expected focusing to start the task, status %v", task.Status)
+	}
+	if view := m.View(); !strings.Contains(view, "Pomodoro 1") || !strings.Contains(view, "Write runbook") {
+		t.Errorf("unexpected focus view:\n%s", view)
+	}
+
+	// A tick before the end just keeps counting; one from an old session is dropped
+	if _, cmd := m.Update(focusTickMsg{session: m.focusSession, at: m.focusEnd.Add(-time.Minute)}); cmd == nil || m.focusPhase != focusWork {
+		t.Error("expected an early tick to schedule the next one")
+	}
+	if _, cmd := m.Update(focusTickMsg{session: m.focusSession - 1, at: m.focusEnd}); cmd != nil || m.focusPhase != focusWork {
+		t.Error("expected a stale tick to be ignored")
+	}
+
+	if cmd := finishFocusPhase(m); cmd != nil {
+		cmd()
+	}
+	if m.focusPhase != focusWorkDone || rung != 1 {
+		t.Fatalf("expected the pomodoro to end with a bell, phase %v, rung %d", m.focusPhase, rung)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if m.focusPhase != focusBreak || m.focusEnd.Sub(m.focusStarted) != 5*time.Minute {
+		t.Fatalf("expected c to start a 5m break, phase %v", m.focusPhase)
+	}
+	if cmd := finishFocusPhase(m); cmd != nil {
+		cmd()
+	}
+	if m.focusPhase != focusBreakDone || rung != 2 {
+		t.Fatalf("expected the break to end with a bell, phase %v, rung %d", m.focusPhase, rung)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.focusPhase != focusWork {
+		t.Fatalf("expected enter to start the next pomodoro, phase %v", m.focusPhase)
+	}
+	finishFocusPhase(m)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if m.mode != modeTable {
+		t.Fatalf("expected d to return to the table, mode %v", m.mode)
+	}
+
+	task := m.tasks[0]
+	if task.Status != StatusDone || task.Pomodoros != 2 {
+		t.Errorf("expected the task done with 2 pomodoros, got status %v, %d pomodoros", task.Status, task.Pomodoros)
+	}
+	if !strings.Contains(m.table.View(), "Write runbook (2 pomodoros)") {
+		t.Errorf("expected the row to show the pomodoro count:\n%s", m.table.View())
+	}
+	events, _ := s.GetTaskEvents(task.ID)
+	breaks := 0
+	for _, e := range events {
+		if e.Event == "break" {
+			breaks++
+		}
+	}
+	if breaks != 1 {
+		t.Errorf("expected 1 break recorded, got %d", breaks)
+	}
+}
+
+func TestFocusModePauses(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Write runbook", PriorityA, "2h", "default")
+	m := newModel(s, "2025-06-01", "default", false)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	if !m.focusPaused || !strings.Contains(m.View(), "(paused)") {
+		t.Fatal("expected space to pause")
+	}
+	if _, cmd := m.Update(focusTickMsg{session: m.focusSession, at: m.focusEnd.Add(time.Hour)}); cmd != nil || m.focusPhase != focusWork {
+		t.Error("expected no progress while paused")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	if m.focusPaused || cmd == nil {
+		t.Error("expected space to resume the countdown")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != modeTable || m.tasks[0].Pomodoros != 0 {
+		t.Errorf("expected esc to stop without counting a pomodoro, mode %v", m.mode)
+	}
+}
+
+func TestFocusModeRefusesFinishedTask(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Already done", PriorityA, "1h", "default")
+	tasks, _ := s.GetTasksForDate("2025-06-01", "default")
+	s.MarkComplete(tasks[0].ID)
+
+	m := newModel(s, "2025-06-01", "default", false)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if m.mode != modeTable || m.status == "" {
+		t.Errorf("expected a message instead of focus mode, mode %v status %q", m.mode, m.status)
+	}
+}
+
+func TestBigClock(t *testing.T) {
+	got := bigClock(25*time.Minute + 3*time.Second)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d:\n%s", len(lines), got)
+	}
+	if lines[0] != "███ ███   ███ ███" {
+		t.Errorf("top row = %q\n%s", lines[0], got)
+	}
+}
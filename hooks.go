@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hooks tell other tools when tasks change: executables in the hooks directory next to
+// config.toml, named after the event (task.done, task.done.sh, ...), get the event as JSON on
+// stdin, and URLs listed under [hooks] in the config get it as a POST body. Hooks run one at a
+// time in the background after the change is committed, so a slow or failing hook can't hold
+// up or undo a write; failures are only reported.
+
+// hookEvents are the events hooks can subscribe to. "*" in [hooks] means all of them.
+var hookEvents = []string{
+	"task.added", "task.edited", "task.moved", "task.deleted",
+	"task.started", "task.done", "task.reopened", "task.blocked", "task.delegated", "task.cancelled",
+	"tasks.carried",
+}
+
+// hookStatusEvents names the event for moving a task to each status.
+var hookStatusEvents = map[Status]string{
+	StatusTodo:       "task.reopened",
+	StatusInProgress: "task.started",
+	StatusDone:       "task.done",
+	StatusBlocked:    "task.blocked",
+	StatusDelegated:  "task.delegated",
+	StatusCancelled:  "task.cancelled",
+}
+
+// hookPayload is the JSON a hook receives. Tasks are in the same shape as the HTTP API's.
+type hookPayload struct {
+	Event   string    `json:"event"`
+	Time    string    `json:"time"`
+	Task    *apiTask  `json:"task,omitempty"`
+	Tasks   []apiTask `json:"tasks,omitempty"` // tasks.carried: the new copies
+	To      string    `json:"to,omitempty"`    // tasks.carried: the day they were carried to
+	Context string    `json:"context,omitempty"`
+}
+
+type hookRunner struct {
+	dir     string              // hooks directory; it needn't exist
+	urls    map[string][]string // event (or "*") → URLs to POST to
+	timeout time.Duration
+	env     []string // extra environment for scripts
+	client  *http.Client
+	stderr  io.Writer
+
+	mu      sync.Mutex
+	queue   []hookPayload
+	running bool
+	wg      sync.WaitGroup
+	hold    bool     // keep warnings until wait, while the TUI owns the terminal
+	held    []string // warnings kept back
+}
+
+// newHookRunner returns nil when no hooks are set up, so the store can skip the work entirely.
+func newHookRunner(dir string, urls map[string][]string, timeout time.Duration, dbPath string) *hookRunner {
+	if len(urls) == 0 {
+		if entries, err := os.ReadDir(dir); err != nil || len(entries) == 0 {
+			return nil
+		}
+	}
+	return &hookRunner{
+		dir:     dir,
+		urls:    urls,
+		timeout: timeout,
+		env:     []string{"GTD_DB=" + dbPath},
+		client:  &http.Client{Timeout: timeout},
+		stderr:  os.Stderr,
+	}
+}
+
+// hooksDir is the hooks directory for a config file: "hooks" beside it.
+func hooksDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "hooks")
+}
+
+// send queues p for delivery and returns straight away.
+func (r *hookRunner) send(p hookPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queue = append(r.queue, p)
+	if !r.running {
+		r.running = true
+		r.wg.Add(1)
+		go r.drain()
+	}
+}
+
+// drain delivers queued events in order until the queue is empty.
+func (r *hookRunner) drain() {
+	defer r.wg.Done()
+	for {
+		r.mu.Lock()
+		if len(r.queue) == 0 {
+			r.running = false
+			r.mu.Unlock()
+			return
+		}
+		p := r.queue[0]
+		r.queue = r.queue[1:]
+		r.mu.Unlock()
+
+		r.deliver(p)
+	}
+}
+
+func (r *hookRunner) deliver(p hookPayload) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		r.warn("hook %s: %v", p.Event, err)
+		return
+	}
+	for _, path := range r.scripts(p.Event) {
+		if err := r.runScript(path, p.Event, body); err != nil {
+			r.warn("hook %s: %v", path, err)
+		}
+	}
+	for _, u := range slices.Concat(r.urls[p.Event], r.urls["*"]) {
+		if err := r.post(u, p.Event, body); err != nil {
+			r.warn("hook %s %s: %v", p.Event, redactURL(u), err)
+		}
+	}
+}
+
+// scripts lists the executables in the hooks directory for event, in name order: a file named
+// after the event, optionally with an extension such as .sh.
+func (r *hookRunner) scripts(event string) []string {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if name != event && !strings.HasPrefix(name, event+".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(r.dir, name))
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(r.dir, name))
+	}
+	return paths
+}
+
+func (r *hookRunner) runScript(path, event string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = r.dir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), append(r.env, "GTD_EVENT="+event)...)
+	cmd.WaitDelay = time.Second // don't wait on children that keep the output open
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", r.timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, firstLine(msg))
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *hookRunner) post(u, event string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sysadmin-gtd")
+	req.Header.Set("X-GTD-Event", event)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+func (r *hookRunner) warn(format string, args ...any) {
+	msg := "Warning: " + fmt.Sprintf(format, args...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hold {
+		r.held = append(r.held, msg)
+		return
+	}
+	fmt.Fprintln(r.stderr, msg)
+}
+
+// holdWarnings keeps hook failures back until wait, so they don't scribble over the TUI.
+func (r *hookRunner) holdWarnings() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.hold = true
+	r.mu.Unlock()
+}
+
+// wait blocks until every queued event has been delivered, then prints any held warnings.
+func (r *hookRunner) wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range r.held {
+		fmt.Fprintln(r.stderr, msg)
+	}
+	r.held, r.hold = nil, false
+}
+
+// redactURL shortens u to its scheme and host for messages, since webhook URLs often carry
+// their secret in the path or query.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "(bad URL)"
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// fireTask runs the hooks for event with the task as it now stands.
+func (s *Store) fireTask(event string, id int64) {
+	if s.hooks == nil {
+		return
+	}
+	t, err := s.GetTask(id)
+	if err != nil {
+		s.hooks.warn("hook %s: %v", event, err)
+		return
+	}
+	s.fireTaskValue(event, t)
+}
+
+func (s *Store) fireTaskValue(event string, t Task) {
+	if s.hooks == nil {
+		return
+	}
+	a := newAPITask(t)
+	s.hooks.send(hookPayload{Event: event, Time: s.timestamp(), Task: &a})
+}
+
+// fireCarried runs the tasks.carried hooks with the new copies of the carried tasks.
+func (s *Store) fireCarried(ids []int64, to, context string) {
+	if s.hooks == nil || len(ids) == 0 {
+		return
+	}
+	p := hookPayload{Event: "tasks.carried", Time: s.timestamp(), To: to, Context: context}
+	for _, id := range ids {
+		t, err := s.GetTask(id)
+		if err != nil {
+			s.hooks.warn("hook %s: %v", p.Event, err)
+			return
+		}
+		p.Tasks = append(p.Tasks, newAPITask(t))
+	}
+	s.hooks.send(p)
+}
+
+// validateHooks checks the [hooks] table: known events, and http or https URLs.
+func validateHooks(hooks map[string][]string) error {
+	for event, urls := range hooks {
+		if event != "*" && !slices.Contains(hookEvents, event) {
+			return fmt.Errorf("unknown hook event %q (available: %s)", event, strings.Join(hookEvents, ", "))
+		}
+		for _, u := range urls {
+			parsed, err := url.Parse(u)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("hook %s: %q isn't an http or https URL", event, u)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useHooks attaches a hook runner to s whose warnings go to the returned builder.
+func useHooks(t *testing.T, s *Store, dir string, urls map[string][]string, timeout time.Duration) *strings.Builder {
+	t.Helper()
+	s.hooks = newHookRunner(dir, urls, timeout, s.path)
+	if s.hooks == nil {
+		t.Fatal("expected a hook runner")
+	}
+	warnings := &strings.Builder{}
+	s.hooks.stderr = warnings
+	return warnings
+}
+
+func writeHook(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHookURLs(t *testing.T) {
+	var mu sync.Mutex
+	var got []hookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p hookPayload
+		json.NewDecoder(r.Body).Decode(&p)
+		if r.Header.Get("X-GTD-Event") != p.Event || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected headers %v for %s", r.Header, p.Event)
+		}
+		mu.Lock()
+		got = append(got, p)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := newTestStore(t)
+	warnings := useHooks(t, s, t.TempDir(), map[string][]string{"*": {srv.URL}}, time.Second)
+
+	id, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Patch web servers", Priority: PriorityA, Context: "work"})
+	s.MarkInProgress(id)
+	s.MarkInProgress(id) // no change, no event
+	s.MarkComplete(id)
+	s.UpdateTask(id, "Patch all web servers", PriorityA, "", "", "")
+	task, _ := s.GetTask(id)
+	s.CarryOverTasks([]Task{task}, "2025-06-03", "work")
+	s.DeleteTask(id)
+	s.hooks.wait()
+
+	var events []string
+	for _, p := range got {
+		events = append(events, p.Event)
+	}
+	if strings.Join(events, " ") != "task.added task.started task.done task.edited tasks.carried task.deleted" {
+		t.Fatalf("events = %v", events)
+	}
+	if got[2].Task == nil || got[2].Task.Status != "done" || got[2].Task.CompletedAt == "" || got[2].Time == "" {
+		t.Errorf("expected the completed task in task.done, got %+v", got[2])
+	}
+	if carried := got[4]; carried.To != "2025-06-03" || carried.Context != "work" || len(carried.Tasks) != 1 ||
+		carried.Tasks[0].Date != "2025-06-03" || carried.Tasks[0].CarriedFromID == nil {
+		t.Errorf("unexpected tasks.carried payload: %+v", carried)
+	}
+	if deleted := got[5].Task; deleted == nil || deleted.ID != id || deleted.Description != "Patch all web servers" {
+		t.Errorf("expected the deleted task as it was, got %+v", deleted)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("unexpected warnings: %s", warnings)
+	}
+}
+
+func TestHookBulkChanges(t *testing.T) {
+	var mu sync.Mutex
+	var got []hookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p hookPayload
+		json.NewDecoder(r.Body).Decode(&p)
+		mu.Lock()
+		got = append(got, p)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := newTestStore(t)
+	s.CreateTask(Task{Date: "2025-06-02", Description: "Fix the fence", Priority: PriorityB, Context: "home"})
+	warnings := useHooks(t, s, t.TempDir(), map[string][]string{"*": {srv.URL}}, time.Second)
+
+	imported := []Task{
+		{UID: "a@example.com", Date: "2025-06-02", Description: "Renew the TLS cert", Priority: PriorityA, Context: "work"},
+		{UID: "b@example.com", Date: "2025-06-02", Description: "Order toner", Priority: PriorityC, Context: "work"},
+	}
+	s.ImportTasks(imported, "tasks.ics")
+	s.ImportTasks(imported, "tasks.ics") // already there, no events
+	s.CopyIncompleteTasks("2025-06-02", "2025-06-03", "work")
+	s.MergeContext("home", "work")
+	s.DeleteContext("work")
+	s.hooks.wait()
+
+	var events []string
+	for _, p := range got {
+		events = append(events, p.Event)
+	}
+	want := "task.added task.added task.added task.added task.moved" + strings.Repeat(" task.deleted", 5)
+	if strings.Join(events, " ") != want {
+		t.Fatalf("events = %v", events)
+	}
+	if added := got[0].Task; added == nil || added.Description != "Renew the TLS cert" || added.Context != "work" {
+		t.Errorf("expected the imported task in task.added, got %+v", added)
+	}
+	if copied := got[2].Task; copied == nil || copied.Date != "2025-06-03" {
+		t.Errorf("expected the copy in task.added, got %+v", copied)
+	}
+	if moved := got[4].Task; moved == nil || moved.Description != "Fix the fence" || moved.Context != "work" {
+		t.Errorf("expected the merged task in its new context, got %+v", moved)
+	}
+	if deleted := got[5].Task; deleted == nil || deleted.Context != "work" || deleted.Description == "" {
+		t.Errorf("expected the deleted task as it was, got %+v", deleted)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("unexpected warnings: %s", warnings)
+	}
+}
+
+func TestHookScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts here are shell scripts")
+	}
+	dir := t.TempDir()
+	out := t.TempDir()
+	writeHook(t, dir, "task.done.sh", `cat > "`+out+`/done.json"; echo "$GTD_EVENT" > "`+out+`/event"`)
+	writeHook(t, dir, "task.doneish", `touch "`+out+`/wrong"`)
+	os.WriteFile(filepath.Join(dir, "task.done.txt"), []byte("not executable"), 0o644)
+
+	s := newTestStore(t)
+	warnings := useHooks(t, s, dir, nil, time.Second)
+	id, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Order toner", Priority: PriorityC, Context: "default"})
+	s.MarkComplete(id)
+	s.hooks.wait()
+
+	data, err := os.ReadFile(filepath.Join(out, "done.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p hookPayload
+	if err := json.Unmarshal(data, &p); err != nil || p.Event != "task.done" || p.Task.Description != "Order toner" {
+		t.Errorf("unexpected payload %s (%v)", data, err)
+	}
+	if event, _ := os.ReadFile(filepath.Join(out, "event")); string(event) != "task.done\n" {
+		t.Errorf("GTD_EVENT = %q", event)
+	}
+	if _, err := os.Stat(filepath.Join(out, "wrong")); err == nil {
+		t.Error("a hook for another event ran")
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("unexpected warnings: %s", warnings)
+	}
+}
+
+func TestHookFailures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts here are shell scripts")
+	}
+	dir := t.TempDir()
+	writeHook(t, dir, "task.added", "echo 'ticket system down' >&2; exit 3")
+	writeHook(t, dir, "task.done", "sleep 5")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s := newTestStore(t)
+	warnings := useHooks(t, s, dir, map[string][]string{"task.added": {srv.URL + "/secret-path?token=abc"}}, 200*time.Millisecond)
+	s.hooks.holdWarnings()
+
+	start := time.Now()
+	id, err := s.CreateTask(Task{Date: "2025-06-02", Description: "Order toner", Priority: PriorityC, Context: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkComplete(id); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Error("the store waited for its hooks")
+	}
+	if warnings.Len() != 0 {
+		t.Error("warnings should be held until wait")
+	}
+	s.hooks.wait()
+
+	// Every write stuck, and every failure was reported without giving away the URL's secret
+	if task, _ := s.GetTask(id); task.Status != StatusDone {
+		t.Errorf("expected the task done despite its hooks, got %+v", task)
+	}
+	for _, want := range []string{"exit status 3: ticket system down", "500 Internal Server Error", "timed out after 200ms"} {
+		if !strings.Contains(warnings.String(), want) {
+			t.Errorf("expected a warning containing %q, got:\n%s", want, warnings)
+		}
+	}
+	if strings.Contains(warnings.String(), "secret") || strings.Contains(warnings.String(), "abc") {
+		t.Errorf("a warning leaked the hook URL:\n%s", warnings)
+	}
+}
+
+func TestNewHookRunner(t *testing.T) {
+	if r := newHookRunner(filepath.Join(t.TempDir(), "missing"), nil, time.Second, ""); r != nil {
+		t.Error("expected no runner without a hooks directory or URLs")
+	}
+	if r := newHookRunner(t.TempDir(), nil, time.Second, ""); r != nil {
+		t.Error("expected no runner for an empty hooks directory")
+	}
+	var r *hookRunner
+	r.holdWarnings()
+	r.wait() // a nil runner is fine to use
+}
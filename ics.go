@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// iCalendar (RFC 5545) export and import. Each task is a VTODO. Fields without a standard
+// property (the planned day, time estimate, and the blocked and delegated statuses) use X-GTD-
+// properties so a round trip through gtd keeps them; other apps ignore those.
+
+const icsTimestamp = "20060102T150405Z"
+
+// icsPriority maps A–D onto iCalendar's 1 (highest) to 9 (lowest) so apps with three levels
+// show A as high, B as medium, and C and D as low.
+var icsPriority = map[Priority]int{PriorityA: 1, PriorityB: 5, PriorityC: 7, PriorityD: 9}
+
+// priorityFromICS is the reverse of icsPriority; 0 means "undefined" and gets the default.
+func priorityFromICS(n int) Priority {
+	switch {
+	case n >= 1 && n <= 4:
+		return PriorityA
+	case n == 5:
+		return PriorityB
+	case n == 6 || n == 7:
+		return PriorityC
+	case n >= 8:
+		return PriorityD
+	}
+	return config.DefaultPriority
+}
+
+// writeICS writes tasks as an iCalendar file of VTODO components.
+func writeICS(w io.Writer, tasks []Task) error {
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	stamp := time.Now().UTC().Format(icsTimestamp)
+
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//sysadmin-gtd//gtd//EN")
+	for _, t := range tasks {
+		iw.line("BEGIN", "VTODO")
+		iw.line("UID", t.UID)
+		iw.line("DTSTAMP", stamp)
+		if !t.CreatedAt.IsZero() {
+			iw.line("CREATED", t.CreatedAt.UTC().Format(icsTimestamp))
+		}
+		if !t.UpdatedAt.IsZero() {
+			iw.line("LAST-MODIFIED", t.UpdatedAt.UTC().Format(icsTimestamp))
+		}
+		iw.line("SUMMARY", icsEscape(t.Description))
+		if t.Notes != "" {
+			iw.line("DESCRIPTION", icsEscape(t.Notes))
+		}
+		// DUE has to be after DTSTART, so a task planned on or after its deadline only gets a DUE
+		if !t.HasDueDate() || t.DueDate > t.Date {
+			iw.line("DTSTART;VALUE=DATE", icsDate(t.Date))
+		}
+		if t.HasDueDate() {
+			iw.line("DUE;VALUE=DATE", icsDate(t.DueDate))
+		}
+		iw.line("PRIORITY", strconv.Itoa(icsPriority[t.Priority]))
+
+		switch t.Status {
+		case StatusDone:
+			iw.line("STATUS", "COMPLETED")
+			if !t.CompletedAt.IsZero() {
+				iw.line("COMPLETED", t.CompletedAt.UTC().Format(icsTimestamp))
+			}
+		case StatusInProgress:
+			iw.line("STATUS", "IN-PROCESS")
+		case StatusCancelled:
+			iw.line("STATUS", "CANCELLED")
+		default:
+			iw.line("STATUS", "NEEDS-ACTION")
+		}
+		switch t.Status {
+		case StatusBlocked:
+			iw.line("X-GTD-STATUS", "blocked")
+		case StatusDelegated:
+			iw.line("X-GTD-STATUS", "delegated")
+		}
+
+		iw.line("CATEGORIES", icsEscape(t.Context))
+		iw.line("X-GTD-DATE", icsDate(t.Date))
+		if t.TimeEstimate != "" {
+			iw.line("X-GTD-ESTIMATE", icsEscape(t.TimeEstimate))
+		}
+		iw.line("END", "VTODO")
+	}
+	iw.line("END", "VCALENDAR")
+
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line, folded so no physical line is longer than 75 octets.
+func (iw *icsWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+	s := name + ":" + value
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		_, iw.err = iw.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	if iw.err == nil {
+		_, iw.err = iw.w.WriteString(s + "\r\n")
+	}
+}
+
+func icsDate(date string) string {
+	return strings.ReplaceAll(date, "-", "")
+}
+
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func icsUnescape(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// readICS parses the VTODO components of an iCalendar file into tasks. Other components, such
+// as events, are skipped. Tasks have no context unless the VTODO has CATEGORIES.
+func readICS(r io.Reader) ([]Task, error) {
+	lines, err := icsLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	var t *Task
+	for _, line := range lines {
+		name, value, ok := icsProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			t = &Task{Priority: config.DefaultPriority}
+		case t == nil:
+			continue
+		case name == "END" && strings.EqualFold(value, "VTODO"):
+			if t.Date == "" {
+				t.Date = t.DueDate
+			}
+			if t.Date == "" {
+				t.Date = time.Now().Format("2006-01-02")
+			}
+			tasks = append(tasks, *t)
+			t = nil
+		default:
+			if err := setICSProperty(t, name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if t != nil {
+		return nil, fmt.Errorf("unterminated VTODO")
+	}
+	return tasks, nil
+}
+
+// icsLines reads content lines, joining folded continuation lines back together.
+func icsLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// icsProperty splits a content line into its upper-cased name (parameters dropped) and value.
+func icsProperty(line string) (name, value string, ok bool) {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			name, _, _ = strings.Cut(line[:i], ";")
+			return strings.ToUpper(name), line[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func setICSProperty(t *Task, name, value string) error {
+	switch name {
+	case "UID":
+		t.UID = value
+	case "SUMMARY":
+		t.Description = strings.TrimSpace(icsUnescape(value))
+	case "DESCRIPTION":
+		t.Notes = strings.TrimSpace(icsUnescape(value))
+	case "DTSTART", "X-GTD-DATE", "DUE":
+		date, err := dateFromICS(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		switch {
+		case name == "DUE":
+			t.DueDate = date
+		case name == "X-GTD-DATE" || t.Date == "":
+			t.Date = date
+		}
+	case "PRIORITY":
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("PRIORITY: expected a number, got %q", value)
+		}
+		t.Priority = priorityFromICS(n)
+	case "STATUS":
+		switch strings.ToUpper(value) {
+		case "COMPLETED":
+			t.Status = StatusDone
+		case "IN-PROCESS":
+			if t.Status == StatusTodo {
+				t.Status = StatusInProgress
+			}
+		case "CANCELLED":
+			t.Status = StatusCancelled
+		}
+	case "X-GTD-STATUS":
+		switch strings.ToLower(value) {
+		case "blocked":
+			t.Status = StatusBlocked
+		case "delegated":
+			t.Status = StatusDelegated
+		}
+	case "COMPLETED":
+		t.CompletedAt = timeFromICS(value)
+		if t.Status == StatusTodo {
+			t.Status = StatusDone
+		}
+	case "CREATED":
+		t.CreatedAt = timeFromICS(value)
+	case "LAST-MODIFIED":
+		t.UpdatedAt = timeFromICS(value)
+	case "CATEGORIES":
+		if t.Context == "" {
+			t.Context = strings.TrimSpace(icsUnescape(splitICSList(value)[0]))
+		}
+	case "X-GTD-ESTIMATE":
+		t.TimeEstimate = icsUnescape(value)
+	}
+	return nil
+}
+
+// dateFromICS reads the date part of a DATE or DATE-TIME value as yyyy-mm-dd.
+func dateFromICS(value string) (string, error) {
+	if len(value) >= 8 {
+		if d, err := time.Parse("20060102", value[:8]); err == nil {
+			return d.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("expected a date such as 20250601, got %q", value)
+}
+
+// timeFromICS reads a DATE-TIME, in UTC when it ends in Z and local time otherwise. Unreadable
+// values give the zero time, which the store treats as unknown.
+func timeFromICS(value string) time.Time {
+	if t, err := time.Parse(icsTimestamp, value); err == nil {
+		return t
+	}
+	t, _ := time.ParseInLocation("20060102T150405", value, time.Local)
+	return t
+}
+
+// splitICSList splits a comma-separated value, leaving escaped commas alone.
+func splitICSList(value string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestICSRoundTrip(t *testing.T) {
+	created := time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)
+	completed := time.Date(2025, 6, 2, 17, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{UID: "a", Date: "2025-06-02", Description: "Renew TLS cert; mail, web", Priority: PriorityA, TimeEstimate: "1h",
+			Status: StatusDone, Context: "work", Notes: "Line one\nLine two", CreatedAt: created, UpdatedAt: completed, CompletedAt: completed},
+		{UID: "b", Date: "2025-06-02", Description: "Chase vendor", Priority: PriorityB, Status: StatusDelegated, Context: "work", DueDate: "2025-06-06"},
+		{UID: "c", Date: "2025-06-03", Description: "Overdue thing", Priority: PriorityC, Status: StatusInProgress, Context: "home", DueDate: "2025-06-01"},
+		{UID: "d", Date: "2025-06-03", Description: "Waiting on DNS", Priority: PriorityD, Status: StatusBlocked, Context: "home"},
+		{UID: "e", Date: "2025-06-03", Description: "Not needed", Priority: PriorityB, Status: StatusCancelled, Context: "home"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeICS(&buf, tasks); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n", "BEGIN:VTODO\r\nUID:a\r\n", `SUMMARY:Renew TLS cert\; mail\, web`, `DESCRIPTION:Line one\nLine two`,
+		"PRIORITY:1\r\n", "PRIORITY:5\r\n", "PRIORITY:7\r\n", "PRIORITY:9\r\n",
+		"STATUS:COMPLETED\r\nCOMPLETED:20250602T170000Z", "STATUS:IN-PROCESS", "STATUS:CANCELLED", "X-GTD-STATUS:delegated",
+		"CATEGORIES:work", "DTSTART;VALUE=DATE:20250602\r\nDUE;VALUE=DATE:20250606", "X-GTD-ESTIMATE:1h",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "DTSTART;VALUE=DATE:20250603\r\nDUE") {
+		t.Error("DTSTART must not be written when DUE isn't after it")
+	}
+
+	got, err := readICS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, tasks) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got, tasks)
+	}
+}
+
+func TestICSFoldsLongLines(t *testing.T) {
+	long := strings.Repeat("Ünïcödé ", 30)
+	var buf bytes.Buffer
+	writeICS(&buf, []Task{{UID: "x", Date: "2025-06-01", Description: long, Priority: PriorityB, Context: "default"}})
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("fold split a character: %q", line)
+		}
+	}
+
+	tasks, err := readICS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Description != strings.TrimSpace(long) {
+		t.Errorf("expected the folded summary back, got %+v", tasks)
+	}
+}
+
+func TestReadICSFromOtherApps(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"PRODID:-//Example//Reminders//EN",
+		"BEGIN:VEVENT",
+		"SUMMARY:A meeting, not a task",
+		"DTSTART:20250601T090000Z",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:reminder-1",
+		"SUMMARY:Order more",
+		"  toner",
+		"DTSTART;TZID=\"Europe/London\":20250604T090000",
+		"PRIORITY:0",
+		"CATEGORIES:office,supplies",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:reminder-2",
+		"SUMMARY:Done elsewhere",
+		"DUE;VALUE=DATE:20250605",
+		"PRIORITY:3",
+		"COMPLETED:20250605T120000Z",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\n")
+
+	tasks, err := readICS(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %+v", tasks)
+	}
+	if got := tasks[0]; got.Description != "Order more toner" || got.Date != "2025-06-04" || got.Priority != config.DefaultPriority || got.Context != "office" {
+		t.Errorf("first task = %+v", got)
+	}
+	if got := tasks[1]; got.Date != "2025-06-05" || got.DueDate != "2025-06-05" || got.Priority != PriorityA || got.Status != StatusDone {
+		t.Errorf("second task = %+v", got)
+	}
+
+	for _, bad := range []string{"BEGIN:VTODO\nSUMMARY:x", "BEGIN:VTODO\nDTSTART:soon\nEND:VTODO", "BEGIN:VTODO\nPRIORITY:high\nEND:VTODO"} {
+		if _, err := readICS(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestPriorityFromICS(t *testing.T) {
+	for n, want := range map[int]Priority{1: PriorityA, 4: PriorityA, 5: PriorityB, 6: PriorityC, 7: PriorityC, 8: PriorityD, 9: PriorityD, 0: PriorityB} {
+		if got := priorityFromICS(n); got != want {
+			t.Errorf("priorityFromICS(%d) = %s, want %s", n, got, want)
+		}
+	}
+	for p, n := range icsPriority {
+		if got := priorityFromICS(n); got != p {
+			t.Errorf("priority %s doesn't survive a round trip (got %s)", p, got)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// The inbox is the capture half of GTD: "gtd capture" writes one-line items into a context's
+// inbox with nothing but their text, and the TUI's processing mode later clarifies each one
+// into a scheduled task, a delegated task, the backlog or nothing. Backlog items stay in the
+// inbox table, flagged, so they're kept out of processing until asked for.
+
+type InboxItem struct {
+	ID        int64
+	Context   string
+	Text      string
+	Backlog   bool
+	CreatedAt time.Time
+}
+
+// CaptureItems adds items to a context's inbox, skipping blank ones, and returns how many
+// were added.
+func (s *Store) CaptureItems(context string, texts []string) (int, error) {
+	n := 0
+	err := s.inTx(func(tx *sql.Tx) error {
+		now := s.timestamp()
+		for _, text := range texts {
+			text = strings.TrimSpace(text)
+			if text == "" {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO inbox (context, text, created_at) VALUES (?, ?, ?)`, context, text, now); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// GetInboxItems returns a context's inbox in the order it was captured, or its backlog. An
+// empty context returns every context's.
+func (s *Store) GetInboxItems(context string, backlog bool) ([]InboxItem, error) {
+	where, args := "backlog = ?", []any{backlog}
+	if context != "" {
+		where += " AND context = ?"
+		args = append(args, context)
+	}
+	rows, err := s.db.Query(`SELECT id, context, text, backlog, created_at FROM inbox WHERE `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []InboxItem
+	for rows.Next() {
+		var item InboxItem
+		var createdAt string
+		if err := rows.Scan(&item.ID, &item.Context, &item.Text, &item.Backlog, &createdAt); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = parseTimestamp(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ScheduleInboxItem turns an inbox item into a task, taking the item out of the inbox, and
+// returns the task's ID. t carries everything but the context, which comes from the item.
+func (s *Store) ScheduleInboxItem(id int64, t Task) (int64, error) {
+	var taskID int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		if err := tx.QueryRow(`SELECT context FROM inbox WHERE id = ?`, id).Scan(&t.Context); err != nil {
+			return inboxItemErr(id, err)
+		}
+		var err error
+		if taskID, err = s.insertTask(tx, t); err != nil {
+			return err
+		}
+		if err := s.logEvent(tx, taskID, "created", "from inbox"); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM inbox WHERE id = ?`, id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.fireTask("task.added", taskID)
+	if t.Status != StatusTodo {
+		s.fireTask(hookStatusEvents[t.Status], taskID)
+	}
+	return taskID, nil
+}
+
+// BacklogInboxItem parks an inbox item in the backlog.
+func (s *Store) BacklogInboxItem(id int64) error {
+	return s.changeInboxItem(`UPDATE inbox SET backlog = 1 WHERE id = ?`, id)
+}
+
+// DeleteInboxItem throws an inbox or backlog item away.
+func (s *Store) DeleteInboxItem(id int64) error {
+	return s.changeInboxItem(`DELETE FROM inbox WHERE id = ?`, id)
+}
+
+func (s *Store) changeInboxItem(query string, id int64) error {
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return inboxItemErr(id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+func inboxItemErr(id int64, err error) error {
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no inbox item %d", id)
+	}
+	return err
+}
+
+// runCapture implements "gtd capture <text>|- [--context x]".
+func runCapture(store *Store, args []string) error {
+	context := config.DefaultContext
+	var words []string
+	for i := 0; i < len(args); i++ {
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		if strings.HasPrefix(args[i], "--") {
+			return fmt.Errorf("unknown argument %q\n%s", args[i], commandUsage("capture"))
+		}
+		words = append(words, args[i])
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("%s", commandUsage("capture"))
+	}
+
+	texts := []string{strings.Join(words, " ")}
+	if len(words) == 1 && words[0] == "-" {
+		texts = nil
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			texts = append(texts, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	if warning, err := store.checkContext(context); err == nil && warning != "" {
+		fmt.Fprintln(os.Stderr, "Warning: "+warning)
+	}
+	n, err := store.CaptureItems(context, texts)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("nothing to capture")
+	}
+	fmt.Printf("Captured %d item(s) to the %s inbox.\n", n, context)
+	return nil
+}
+
+// runInbox implements "gtd inbox [--backlog] [--context x] [--all-contexts]".
+func runInbox(store *Store, args []string) error {
+	context := config.DefaultContext
+	allContexts, backlog := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--all-contexts":
+			allContexts = true
+			continue
+		case "--backlog":
+			backlog = true
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		return fmt.Errorf("unknown argument %q\n%s", args[i], commandUsage("inbox"))
+	}
+	if allContexts {
+		context = ""
+	}
+
+	items, err := store.GetInboxItems(context, backlog)
+	if err != nil {
+		return err
+	}
+	name := "Inbox"
+	if backlog {
+		name = "Backlog"
+	}
+	if !allContexts {
+		name += " · " + context
+	}
+	fmt.Printf("%s · %d item(s)\n", name, len(items))
+	if len(items) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "Captured\tItem"
+	if allContexts {
+		header = "Captured\tContext\tItem"
+	}
+	fmt.Fprintln(w, header)
+	for _, item := range items {
+		row := []string{item.CreatedAt.Local().Format(config.dateLayout()), item.Text}
+		if allContexts {
+			row = []string{row[0], item.Context, row[1]}
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// --- Processing mode ---
+
+// inboxContext is the context whose inbox the TUI shows: the one in view, or "" for every
+// context in the merged view.
+func (m *model) inboxContext() string {
+	if m.allContexts {
+		return ""
+	}
+	return m.context
+}
+
+// loadInbox returns the inbox (or backlog) items in view that haven't been skipped.
+func (m *model) loadInbox(backlog bool) []InboxItem {
+	items, err := m.store.GetInboxItems(m.inboxContext(), backlog)
+	if err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(items, func(item InboxItem) bool { return m.inboxSkipped[item.ID] })
+}
+
+// processInbox starts walking through the inbox (or backlog), if there's anything in it.
+// Otherwise the mode is left as it is.
+func (m *model) processInbox(backlog bool) (tea.Model, tea.Cmd) {
+	items := m.loadInbox(backlog)
+	if len(items) == 0 {
+		return m, nil
+	}
+	m.inboxItems = items
+	m.inboxBacklog = backlog
+	return m.enterInboxChoice()
+}
+
+// enterInboxMode processes the inbox on request, or the backlog once the inbox is empty.
+func (m *model) enterInboxMode() (tea.Model, tea.Cmd) {
+	m.inboxSkipped = nil
+	backlog := len(m.loadInbox(false)) == 0
+	if backlog && len(m.loadInbox(true)) == 0 {
+		m.status = "The inbox and backlog are empty."
+		return m, nil
+	}
+	return m.processInbox(backlog)
+}
+
+// enterInboxChoice asks what to do with the first item left.
+func (m *model) enterInboxChoice() (tea.Model, tea.Cmd) {
+	options := []huh.Option[string]{huh.NewOption("Schedule it for a day", "schedule")}
+	if !m.inboxBacklog {
+		options = append(options, huh.NewOption("Move it to the backlog", "backlog"))
+	}
+	options = append(options,
+		huh.NewOption("Delegate it", "delegate"),
+		huh.NewOption("Delete it", "delete"),
+		huh.NewOption("Leave it for later", "skip"),
+	)
+	m.formChoice = "schedule"
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().Title(m.inboxItems[0].Text).Options(options...).Value(&m.formChoice),
+		),
+	)
+	m.mode = modeInbox
+	return m, m.form.Init()
+}
+
+func (m *model) enterInboxSchedule() (tea.Model, tea.Cmd) {
+	m.formDesc = m.inboxItems[0].Text
+	m.formDate = m.dateInput()
+	m.formPriority = config.DefaultPriority
+	m.formEstimate = ""
+	m.formDue = ""
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().Title("What do you need to do?").Value(&m.formDesc).Validate(notEmpty("Description")),
+			huh.NewInput().Title("Which day? ("+config.DateFormat+")").Value(&m.formDate).Validate(requiredDate),
+			huh.NewSelect[Priority]().Title("Priority?").Options(PriorityOptions()...).Value(&m.formPriority),
+			huh.NewInput().Title("Time estimate? (eg 30m, 2h, 1d)").Value(&m.formEstimate).Validate(notEmpty("Time estimate")),
+			huh.NewInput().Title("Due date? ("+config.DateFormat+", optional)").Value(&m.formDue).Validate(optionalDate),
+		),
+	)
+	m.mode = modeInboxSchedule
+	return m, m.form.Init()
+}
+
+func (m *model) enterInboxDelegate() (tea.Model, tea.Cmd) {
+	m.formDesc = m.inboxItems[0].Text
+	m.formDate = m.dateInput()
+	m.formNotes = ""
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().Title("What's being done?").Value(&m.formDesc).Validate(notEmpty("Description")),
+			huh.NewInput().Title("Who's doing it? (optional)").Value(&m.formNotes),
+			huh.NewInput().Title("Day to follow it up? ("+config.DateFormat+")").Value(&m.formDate).Validate(requiredDate),
+		),
+	)
+	m.mode = modeInboxDelegate
+	return m, m.form.Init()
+}
+
+// handleInboxForm acts on a completed processing form, then moves on to the next item.
+func (m *model) handleInboxForm() (tea.Model, tea.Cmd) {
+	item := m.inboxItems[0]
+	var err error
+	switch m.mode {
+	case modeInbox:
+		switch m.formChoice {
+		case "schedule":
+			return m.enterInboxSchedule()
+		case "delegate":
+			return m.enterInboxDelegate()
+		case "backlog":
+			err = m.store.BacklogInboxItem(item.ID)
+			m.status = "Moved to the backlog."
+		case "delete":
+			err = m.store.DeleteInboxItem(item.ID)
+			m.status = "Item deleted."
+		case "skip":
+			if m.inboxSkipped == nil {
+				m.inboxSkipped = map[int64]bool{}
+			}
+			m.inboxSkipped[item.ID] = true
+			m.status = "Left for later."
+		}
+
+	case modeInboxSchedule:
+		date, _ := parseInputDate(m.formDate)
+		_, err = m.store.ScheduleInboxItem(item.ID, Task{
+			Date:         date,
+			Description:  strings.TrimSpace(m.formDesc),
+			Priority:     m.formPriority,
+			TimeEstimate: m.formEstimate,
+			DueDate:      formDueDate(m.formDue),
+		})
+		m.status = "Task scheduled for " + m.formDate + "."
+
+	case modeInboxDelegate:
+		date, _ := parseInputDate(m.formDate)
+		notes := ""
+		if who := strings.TrimSpace(m.formNotes); who != "" {
+			notes = "Delegated to " + who
+		}
+		_, err = m.store.ScheduleInboxItem(item.ID, Task{
+			Date:        date,
+			Description: strings.TrimSpace(m.formDesc),
+			Priority:    config.DefaultPriority,
+			Status:      StatusDelegated,
+			Notes:       notes,
+		})
+		m.status = "Task delegated, to follow up on " + m.formDate + "."
+	}
+	if err != nil {
+		m.status = "Error processing the item."
+	}
+
+	m.refreshTasks()
+	if m.inboxItems = m.loadInbox(m.inboxBacklog); len(m.inboxItems) > 0 {
+		return m.enterInboxChoice()
+	}
+	m.mode = modeTable
+	if err == nil {
+		name := "Inbox"
+		if m.inboxBacklog {
+			name = "Backlog"
+		}
+		if len(m.inboxSkipped) > 0 {
+			m.status = fmt.Sprintf("%s processed; %d item(s) left for later.", name, len(m.inboxSkipped))
+		} else {
+			m.status = name + " processed."
+		}
+	}
+	return m, nil
+}
+
+// inboxView is the heading over the processing forms.
+func (m *model) inboxView() string {
+	name := "Inbox"
+	if m.inboxBacklog {
+		name = "Backlog"
+	}
+	item := m.inboxItems[0]
+	heading := fmt.Sprintf("  %s · %d left · captured %s", name, len(m.inboxItems), item.CreatedAt.Local().Format(config.dateLayout()))
+	if m.allContexts {
+		heading += " · " + item.Context
+	}
+	heading = infoStyle.Render(heading)
+	if m.status != "" {
+		heading += statusStyle.Render("  " + m.status)
+	}
+	return heading + "\n\n"
+}
+
+// dateInput is the day being viewed, in the configured format, as a form's starting value.
+func (m *model) dateInput() string {
+	date, _ := time.Parse("2006-01-02", m.date)
+	return date.Format(config.dateLayout())
+}
+
+// requiredDate validates a form field that must be a date in the configured format.
+func requiredDate(s string) error {
+	_, err := parseInputDate(s)
+	return err
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestInboxStore(t *testing.T) {
+	s := newTestStore(t)
+	n, err := s.CaptureItems("work", []string{"Renew the TLS cert", "  ", "Ask about the rack move "})
+	if err != nil || n != 2 {
+		t.Fatalf("CaptureItems = %d, %v; want 2 items", n, err)
+	}
+	s.CaptureItems("home", []string{"Fix the fence"})
+
+	items, _ := s.GetInboxItems("work", false)
+	if len(items) != 2 || items[0].Text != "Renew the TLS cert" || items[1].Text != "Ask about the rack move" {
+		t.Fatalf("unexpected work inbox %+v", items)
+	}
+	if all, _ := s.GetInboxItems("", false); len(all) != 3 {
+		t.Errorf("expected 3 items across every context, got %d", len(all))
+	}
+
+	id, err := s.ScheduleInboxItem(items[0].ID, Task{Date: "2025-06-02", Description: "Renew the TLS cert", Priority: PriorityA, TimeEstimate: "30m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _ := s.GetTask(id)
+	if task.Context != "work" || task.Priority != PriorityA || task.Date != "2025-06-02" {
+		t.Errorf("unexpected scheduled task %+v", task)
+	}
+	if events, _ := s.GetTaskEvents(id); len(events) != 1 || events[0].Detail != "from inbox" {
+		t.Errorf("expected a created event from the inbox, got %+v", events)
+	}
+	if _, err := s.ScheduleInboxItem(items[0].ID, Task{Date: "2025-06-02", Description: "Again"}); err == nil {
+		t.Error("expected an error scheduling an item that's already gone")
+	}
+
+	if err := s.BacklogInboxItem(items[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if inbox, _ := s.GetInboxItems("work", false); len(inbox) != 0 {
+		t.Errorf("expected the work inbox empty, got %+v", inbox)
+	}
+	if backlog, _ := s.GetInboxItems("work", true); len(backlog) != 1 || !backlog[0].Backlog {
+		t.Errorf("expected one backlog item, got %+v", backlog)
+	}
+	if err := s.DeleteInboxItem(items[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteInboxItem(items[1].ID); err == nil {
+		t.Error("expected an error deleting a missing item")
+	}
+}
+
+func TestInboxFollowsContext(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-02", "Patch web servers", PriorityA, "1h", "wrok")
+	s.AddTask("2025-06-02", "Fix the fence", PriorityB, "1h", "home")
+	s.CaptureItems("wrok", []string{"Renew the TLS cert"})
+	s.CaptureItems("home", []string{"Buy paint"})
+
+	if err := s.RenameContext("wrok", "work"); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := s.GetInboxItems("work", false); len(items) != 1 {
+		t.Errorf("expected the inbox to follow the rename, got %+v", items)
+	}
+	if _, err := s.DeleteContext("home"); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := s.GetInboxItems("home", false); len(items) != 0 {
+		t.Errorf("expected the inbox deleted with its context, got %+v", items)
+	}
+}
+
+func TestInboxOnlyContext(t *testing.T) {
+	s := newTestStore(t)
+	s.CaptureItems("ideas", []string{"Learn Rust"})
+
+	if warning, err := s.checkContext("ideas"); err != nil || warning != "" {
+		t.Errorf("expected an inbox-only context to be known, got %q (%v)", warning, err)
+	}
+	if err := s.RenameContext("ideas", "someday"); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := s.GetInboxItems("someday", false); len(items) != 1 {
+		t.Errorf("expected the inbox renamed, got %+v", items)
+	}
+	s.SetContextArchived("someday", true)
+	if warning, _ := s.checkContext("someday"); warning != `Context "someday" is archived.` {
+		t.Errorf("unexpected warning %q", warning)
+	}
+}
+
+func TestRunCapture(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-02", "Patch web servers", PriorityA, "1h", "work")
+
+	msg := captureOutput(t, func() error { return runCapture(s, []string{"Renew", "the", "cert", "--context", "work"}) })
+	if msg != "Captured 1 item(s) to the work inbox.\n" {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	stdin := filepath.Join(t.TempDir(), "dump.txt")
+	os.WriteFile(stdin, []byte("Order toner\n\nCall the ISP\r\nBook leave"), 0o644)
+	f, _ := os.Open(stdin)
+	defer f.Close()
+	saved := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() { os.Stdin = saved })
+	msg = captureOutput(t, func() error { return runCapture(s, []string{"-"}) })
+	if msg != "Captured 3 item(s) to the default inbox.\n" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	items, _ := s.GetInboxItems("default", false)
+	if len(items) != 3 || items[1].Text != "Call the ISP" || items[2].Text != "Book leave" {
+		t.Errorf("unexpected inbox %+v", items)
+	}
+
+	for _, args := range [][]string{nil, {"   "}, {"x", "--bogus"}, {"x", "--context"}} {
+		if err := runCapture(s, args); err == nil {
+			t.Errorf("runCapture(%q): expected an error", args)
+		}
+	}
+}
+
+func TestRunInbox(t *testing.T) {
+	s := newTestStore(t)
+	s.CaptureItems("default", []string{"Order toner"})
+	s.CaptureItems("work", []string{"Renew the TLS cert", "Ask about the rack move"})
+	items, _ := s.GetInboxItems("work", false)
+	s.BacklogInboxItem(items[1].ID)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"Inbox · default · 1 item(s)", "Order toner"}},
+		{[]string{"--all-contexts"}, []string{"Inbox · 2 item(s)", "Context  Item", "work     Renew the TLS cert"}},
+		{[]string{"--backlog", "--context", "work"}, []string{"Backlog · work · 1 item(s)", "Ask about the rack move"}},
+		{[]string{"--context", "home"}, []string{"Inbox · home · 0 item(s)\n"}},
+	}
+	for _, tt := range tests {
+		out := captureOutput(t, func() error { return runInbox(s, tt.args) })
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("runInbox(%q): expected %q in:\n%s", tt.args, want, out)
+			}
+		}
+	}
+	if err := runInbox(s, []string{"stuff"}); err == nil {
+		t.Error("expected an error for an unknown argument")
+	}
+}
+
+func TestInboxProcessing(t *testing.T) {
+	s := newTestStore(t)
+	s.CaptureItems("default", []string{"Renew the TLS cert", "Chase the rack move", "Someday: learn Rust", "Old idea", "Think about it"})
+
+	m := newModel(s, "2025-06-02", "default", false)
+	if m.mode != modeInbox || len(m.inboxItems) != 5 || m.Init() == nil {
+		t.Fatalf("expected the TUI to open on the inbox, mode %v", m.mode)
+	}
+	if view := m.View(); !strings.Contains(view, "Inbox · 5 left") || !strings.Contains(view, "Renew the TLS cert") {
+		t.Errorf("unexpected processing view:\n%s", view)
+	}
+
+	// Schedule, with a form that starts on the day in view
+	m.formChoice = "schedule"
+	m.handleFormComplete()
+	if m.mode != modeInboxSchedule || m.formDate != "02/06/2025" || m.formDesc != "Renew the TLS cert" {
+		t.Fatalf("expected the schedule form, mode %v date %q", m.mode, m.formDate)
+	}
+	m.formDate, m.formPriority, m.formEstimate = "03/06/2025", PriorityA, "30m"
+	m.handleFormComplete()
+	if tasks, _ := s.GetTasksForDate("2025-06-03", "default"); len(tasks) != 1 || tasks[0].Priority != PriorityA || tasks[0].TimeEstimate != "30m" {
+		t.Errorf("expected the item scheduled for the 3rd, got %+v", tasks)
+	}
+
+	// Delegate, then esc from a form goes back to the choice for the same item
+	m.formChoice = "delegate"
+	m.handleFormComplete()
+	m.updateForm(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != modeInbox || m.inboxItems[0].Text != "Chase the rack move" {
+		t.Fatalf("expected esc to go back to the choice, mode %v", m.mode)
+	}
+	m.formChoice = "delegate"
+	m.handleFormComplete()
+	m.formNotes = "Sam"
+	m.handleFormComplete()
+	tasks, _ := s.GetTasksForDate("2025-06-02", "default")
+	if len(tasks) != 1 || tasks[0].Status != StatusDelegated || tasks[0].Notes != "Delegated to Sam" {
+		t.Errorf("expected a delegated task for today, got %+v", tasks)
+	}
+
+	for _, choice := range []string{"backlog", "delete", "skip"} {
+		m.formChoice = choice
+		m.handleFormComplete()
+	}
+	if m.mode != modeTable || m.status != "Inbox processed; 1 item(s) left for later." {
+		t.Errorf("expected processing to finish, mode %v status %q", m.mode, m.status)
+	}
+	if backlog, _ := s.GetInboxItems("default", true); len(backlog) != 1 || backlog[0].Text != "Someday: learn Rust" {
+		t.Errorf("unexpected backlog %+v", backlog)
+	}
+	if inbox, _ := s.GetInboxItems("default", false); len(inbox) != 1 || inbox[0].Text != "Think about it" || m.inboxCount != 1 {
+		t.Errorf("expected the skipped item still in the inbox, got %+v", inbox)
+	}
+
+	// The inbox key starts over, skipped items included, then moves on to the backlog
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	if m.mode != modeInbox || m.inboxBacklog || len(m.inboxItems) != 1 {
+		t.Fatalf("expected I to reopen the inbox, mode %v", m.mode)
+	}
+	m.formChoice = "delete"
+	m.handleFormComplete()
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	if m.mode != modeInbox || !m.inboxBacklog || strings.Contains(m.form.View(), "backlog") {
+		t.Fatalf("expected I to open the backlog without a backlog choice, mode %v", m.mode)
+	}
+	m.formChoice = "delete"
+	m.handleFormComplete()
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	if m.mode != modeTable || m.status != "The inbox and backlog are empty." {
+		t.Errorf("expected nothing to process, mode %v status %q", m.mode, m.status)
+	}
+
+	if m := newModel(s, "2025-06-02", "default", false); m.mode != modeTable {
+		t.Errorf("expected an empty inbox to leave the TUI on the table, mode %v", m.mode)
+	}
+}
+
+func TestBackupKeepsInbox(t *testing.T) {
+	src := newTestStore(t)
+	src.now = func() time.Time { return time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC) }
+	src.CaptureItems("work", []string{"Renew the TLS cert", "Learn Rust"})
+	items, _ := src.GetInboxItems("work", false)
+	src.BacklogInboxItem(items[1].ID)
+	b, err := src.Backup()
+	if err != nil || len(b.Inbox) != 2 {
+		t.Fatalf("expected the inbox in the backup, got %+v (%v)", b.Inbox, err)
+	}
+
+	dst := newTestStore(t)
+	dst.CaptureItems("home", []string{"Fix the fence"})
+	for range 2 { // merging twice doesn't duplicate
+		if _, _, err := dst.Restore(b, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inbox, _ := dst.GetInboxItems("", false)
+	backlog, _ := dst.GetInboxItems("", true)
+	if len(inbox) != 2 || len(backlog) != 1 || backlog[0].Text != "Learn Rust" {
+		t.Errorf("unexpected inbox %+v and backlog %+v after merging", inbox, backlog)
+	}
+
+	if _, _, err := dst.Restore(b, true); err != nil {
+		t.Fatal(err)
+	}
+	if inbox, _ := dst.GetInboxItems("", false); len(inbox) != 1 || inbox[0].ID != items[0].ID {
+		t.Errorf("expected replace to bring back the inbox as it was, got %+v", inbox)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetCarriedForward maps each task planned between from and to (yyyy-mm-dd, inclusive) that was
+// carried over to another day onto the day it was carried to. An empty context means every context.
+func (s *Store) GetCarriedForward(from, to, context string) (map[int64]string, error) {
+	rows, err := s.db.Query(`
+		SELECT orig.id, MIN(copy.date)
+		FROM tasks orig JOIN tasks copy ON copy.carried_from_id = orig.id
+		WHERE orig.date BETWEEN ? AND ? AND (? = '' OR orig.context = ?)
+		GROUP BY orig.id`, from, to, context, context)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forward := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var date string
+		if err := rows.Scan(&id, &date); err != nil {
+			return nil, err
+		}
+		forward[id] = date
+	}
+	return forward, rows.Err()
+}
+
+// writeJournalDay writes one day's tasks in a context as Markdown with YAML front-matter.
+// Done tasks are ticked, cancelled ones struck through, and anything else is listed with its
+// status; notes follow their task as a quote.
+func writeJournalDay(w io.Writer, date, context string, tasks []Task, forward map[int64]string) error {
+	bw := bufio.NewWriter(w)
+	done := 0
+	for _, t := range tasks {
+		if t.Status == StatusDone {
+			done++
+		}
+	}
+	day, _ := time.Parse("2006-01-02", date)
+
+	fmt.Fprintln(bw, "---")
+	fmt.Fprintf(bw, "date: %s\n", date)
+	fmt.Fprintf(bw, "context: %s\n", yamlString(context))
+	fmt.Fprintf(bw, "tasks: %d\n", len(tasks))
+	fmt.Fprintf(bw, "done: %d\n", done)
+	fmt.Fprintln(bw, "---")
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "# %s, %s\n\n", day.Weekday(), day.Format(config.dateLayout()))
+
+	for _, t := range tasks {
+		box := "[ ]"
+		if t.Status == StatusDone {
+			box = "[x]"
+		}
+		name := t.Description
+		if t.Status == StatusCancelled {
+			name = "~~" + name + "~~"
+		}
+
+		details := []string{}
+		if t.TimeEstimate != "" {
+			details = append(details, "est. "+t.TimeEstimate)
+		}
+		if t.HasDueDate() {
+			details = append(details, "due "+t.DueDate)
+		}
+		if t.Status != StatusTodo && t.Status != StatusDone {
+			details = append(details, t.Status.Name())
+		}
+		if t.WasCarriedOver() {
+			details = append(details, "carried over")
+		}
+		if to, ok := forward[t.ID]; ok {
+			details = append(details, "carried to "+to)
+		}
+		switch {
+		case t.Pomodoros == 1:
+			details = append(details, "1 pomodoro")
+		case t.Pomodoros > 1:
+			details = append(details, fmt.Sprintf("%d pomodoros", t.Pomodoros))
+		}
+
+		line := fmt.Sprintf("- %s **%s** %s", box, t.Priority, name)
+		if len(details) > 0 {
+			line += " — " + strings.Join(details, ", ")
+		}
+		fmt.Fprintln(bw, line)
+		if t.Notes != "" {
+			for _, note := range strings.Split(t.Notes, "\n") {
+				fmt.Fprintln(bw, strings.TrimRight("  > "+note, " "))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _./-]*$`)
+
+// yamlString quotes a value for YAML unless it is plainly safe to leave bare. A JSON-style
+// double-quoted string is valid YAML.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") {
+		if _, err := strconv.ParseFloat(s, 64); err != nil && !isYAMLKeyword(s) {
+			return s
+		}
+	}
+	return strconv.Quote(s)
+}
+
+func isYAMLKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	return false
+}
+
+// runJournal implements "gtd journal [--from d] [--to d] [--dir path] [--context x | --all-contexts]".
+// Each day with tasks becomes <dir>/yyyy-mm-dd.md; with --all-contexts each context gets its own
+// subdirectory. Existing files for those days are overwritten.
+func runJournal(store *Store, args []string) error {
+	usage := commandUsage("journal")
+
+	today := store.now().Format("2006-01-02")
+	from, to, dir := "", today, "journal"
+	context := config.DefaultContext
+	allContexts := false
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--all-contexts" {
+			allContexts = true
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--dir"); ok {
+			if err != nil {
+				return err
+			}
+			dir, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--from"); ok {
+			if err != nil {
+				return err
+			}
+			date, err := parseInputDate(value)
+			if err != nil {
+				return err
+			}
+			from, i = date, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--to"); ok {
+			if err != nil {
+				return err
+			}
+			date, err := parseInputDate(value)
+			if err != nil {
+				return err
+			}
+			to, i = date, next
+			continue
+		}
+		return fmt.Errorf("unknown argument %q\n%s", args[i], usage)
+	}
+	if from == "" {
+		from = to
+	}
+	if from > to {
+		return fmt.Errorf("--from is after --to")
+	}
+	if allContexts {
+		context = ""
+	}
+
+	tasks, err := store.GetTasksInRange(from, to, context)
+	if err != nil {
+		return err
+	}
+	forward, err := store.GetCarriedForward(from, to, context)
+	if err != nil {
+		return err
+	}
+
+	// GetTasksInRange orders by date, then context, so each day's file is a contiguous run
+	written := 0
+	for start := 0; start < len(tasks); {
+		end := start + 1
+		for end < len(tasks) && tasks[end].Date == tasks[start].Date && tasks[end].Context == tasks[start].Context {
+			end++
+		}
+		day := tasks[start:end]
+
+		path := filepath.Join(dir, day[0].Date+".md")
+		if allContexts {
+			path = filepath.Join(dir, contextDirName(day[0].Context), day[0].Date+".md")
+		}
+		if err := writeJournalFile(path, day, forward); err != nil {
+			return err
+		}
+		written++
+		start = end
+	}
+
+	if written == 0 {
+		fmt.Println("No tasks in that range.")
+		return nil
+	}
+	fmt.Printf("Wrote %d journal file(s) to %s.\n", written, dir)
+	return nil
+}
+
+func writeJournalFile(path string, tasks []Task, forward map[int64]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeJournalDay(f, tasks[0].Date, tasks[0].Context, tasks, forward); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// contextDirName makes a context name safe to use as a directory name.
+func contextDirName(context string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, context)
+	if name == "." || name == ".." || name == "" {
+		name = "_" + name
+	}
+	return name
+}
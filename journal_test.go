@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJournalDay(t *testing.T) {
+	carriedFrom := int64(1)
+	tasks := []Task{
+		{ID: 2, Date: "2025-06-02", Description: "Patch web servers", Priority: PriorityA, TimeEstimate: "2h", Status: StatusDone,
+			CarriedFromID: &carriedFrom, Pomodoros: 3, Notes: "web01 first\n\nthen web02"},
+		{ID: 3, Date: "2025-06-02", Description: "Chase vendor", Priority: PriorityB, Status: StatusDelegated, DueDate: "2025-06-06"},
+		{ID: 4, Date: "2025-06-02", Description: "Order toner", Priority: PriorityC, Status: StatusTodo},
+		{ID: 5, Date: "2025-06-02", Description: "Not needed", Priority: PriorityD, Status: StatusCancelled},
+	}
+
+	var b strings.Builder
+	if err := writeJournalDay(&b, "2025-06-02", "side project", tasks, map[int64]string{4: "2025-06-03"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `---
+date: 2025-06-02
+context: side project
+tasks: 4
+done: 1
+---
+
+# Monday, 02/06/2025
+
+- [x] **A** Patch web servers — est. 2h, carried over, 3 pomodoros
+  > web01 first
+  >
+  > then web02
+- [ ] **B** Chase vendor — due 2025-06-06, delegated
+- [ ] **C** Order toner — carried to 2025-06-03
+- [ ] **D** ~~Not needed~~ — cancelled
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	for in, want := range map[string]string{
+		"work":         "work",
+		"side project": "side project",
+		"yes":          `"yes"`,
+		"2025":         `"2025"`,
+		"a: b":         `"a: b"`,
+		"#ops":         `"#ops"`,
+		"":             `""`,
+		"café":         `"café"`,
+	} {
+		if got := yamlString(in); got != want {
+			t.Errorf("yamlString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestRunJournal(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Patch web servers", PriorityA, "2h", "default")
+	s.AddTask("2025-06-02", "Order toner", PriorityC, "5m", "default")
+	s.AddTask("2025-06-02", "Fix the fence", PriorityB, "1d", "home/garden")
+	s.AddTask("2025-06-05", "Out of range", PriorityB, "", "default")
+
+	tasks, _ := s.GetTasksForDate("2025-06-01", "default")
+	s.CarryOverTasks(tasks, "2025-06-02", "default")
+
+	dir := t.TempDir()
+	msg := captureOutput(t, func() error {
+		return runJournal(s, []string{"--from", "01/06/2025", "--to", "02/06/2025", "--dir", dir})
+	})
+	if msg != "Wrote 2 journal file(s) to "+dir+".\n" {
+		t.Errorf("unexpected message: %q", msg)
+	}
+	day1, err := os.ReadFile(filepath.Join(dir, "2025-06-01.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(day1), "Patch web servers — est. 2h, carried to 2025-06-02") {
+		t.Errorf("expected the carried-forward marker:\n%s", day1)
+	}
+	day2, _ := os.ReadFile(filepath.Join(dir, "2025-06-02.md"))
+	if !strings.Contains(string(day2), "tasks: 2") || strings.Contains(string(day2), "Fix the fence") {
+		t.Errorf("expected only the default context's two tasks:\n%s", day2)
+	}
+
+	all := t.TempDir()
+	captureOutput(t, func() error {
+		return runJournal(s, []string{"--from=01/06/2025", "--to=02/06/2025", "--dir=" + all, "--all-contexts"})
+	})
+	home, err := os.ReadFile(filepath.Join(all, "home_garden", "2025-06-02.md"))
+	if err != nil || !strings.Contains(string(home), "context: home/garden") {
+		t.Errorf("expected a file per context, got %q (%v)", home, err)
+	}
+	if _, err := os.Stat(filepath.Join(all, "default", "2025-06-01.md")); err != nil {
+		t.Error(err)
+	}
+
+	msg = captureOutput(t, func() error {
+		return runJournal(s, []string{"--from", "01/01/2025", "--to", "02/01/2025", "--dir", dir})
+	})
+	if msg != "No tasks in that range.\n" {
+		t.Errorf("unexpected message for an empty range: %q", msg)
+	}
+
+	for _, args := range [][]string{{"--bogus"}, {"--from", "June"}, {"--from", "03/06/2025", "--to", "01/06/2025"}, {"--dir"}} {
+		if err := runJournal(s, args); err == nil {
+			t.Errorf("runJournal(%v): expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// keyMap holds the table-mode key bindings. It starts from a preset (keymap in the config) with
+// any [keys] overrides applied, and the help line and ? overlay are generated from it.
+type keyMap struct {
+	Up            key.Binding
+	Down          key.Binding
+	PageUp        key.Binding
+	PageDown      key.Binding
+	HalfPageUp    key.Binding
+	HalfPageDown  key.Binding
+	Top           key.Binding
+	Bottom        key.Binding
+	Add           key.Binding
+	Start         key.Binding
+	Focus         key.Binding
+	Done          key.Binding
+	Blocked       key.Binding
+	Delegated     key.Binding
+	Cancel        key.Binding
+	Edit          key.Binding
+	Delete        key.Binding
+	Carry         key.Binding
+	Import        key.Binding
+	ViewDate      key.Binding
+	History       key.Binding
+	Stats         key.Binding
+	SwitchContext key.Binding
+	Search        key.Binding
+	GlobalSearch  key.Binding
+	Inbox         key.Binding
+	Jump          key.Binding
+	Help          key.Binding
+	Quit          key.Binding
+}
+
+// keyActions are the remappable actions, by the name used under [keys] in the config.
+var keyActions = []struct{ name, desc string }{
+	{"up", "up"},
+	{"down", "down"},
+	{"page_up", "page up"},
+	{"page_down", "page down"},
+	{"half_page_up", "½ page up"},
+	{"half_page_down", "½ page down"},
+	{"top", "first task"},
+	{"bottom", "last task"},
+	{"add", "add"},
+	{"start", "start"},
+	{"focus", "focus (pomodoro)"},
+	{"done", "done"},
+	{"blocked", "blocked"},
+	{"delegated", "delegated"},
+	{"cancel", "cancel"},
+	{"edit", "edit"},
+	{"delete", "delete"},
+	{"carry", "carry"},
+	{"import", "import"},
+	{"view_date", "calendar"},
+	{"history", "history"},
+	{"stats", "stats"},
+	{"switch_context", "context"},
+	{"search", "filter"},
+	{"global_search", "search all dates"},
+	{"inbox", "process inbox"},
+	{"help", "help"},
+	{"quit", "quit"},
+}
+
+// keymapNames lists the presets the keymap setting accepts.
+var keymapNames = []string{"default", "vim", "emacs"}
+
+// keyPreset returns the keys bound to each action in a preset.
+func keyPreset(name string) map[string][]string {
+	keys := map[string][]string{
+		"up":             {"up", "k"},
+		"down":           {"down", "j"},
+		"page_up":        {"pgup"},
+		"page_down":      {"pgdown", "f", " "},
+		"half_page_up":   {"u", "ctrl+u"},
+		"half_page_down": {"ctrl+d"},
+		"top":            {"home", "g"},
+		"bottom":         {"end", "G"},
+		"add":            {"a"},
+		"start":          {"s"},
+		"focus":          {"p"},
+		"done":           {"d"},
+		"blocked":        {"b"},
+		"delegated":      {"w"},
+		"cancel":         {"n"},
+		"edit":           {"e", "enter"},
+		"delete":         {"x"},
+		"carry":          {"c"},
+		"import":         {"i"},
+		"view_date":      {"v"},
+		"history":        {"h"},
+		"stats":          {"S"},
+		"switch_context": {"C"},
+		"search":         {"/"},
+		"global_search":  {"ctrl+f"},
+		"inbox":          {"I"},
+		"help":           {"?"},
+		"quit":           {"q"},
+	}
+
+	switch name {
+	case "vim":
+		keys["page_up"] = []string{"ctrl+b", "pgup"}
+		keys["page_down"] = []string{"ctrl+f", "pgdown", " "}
+		keys["add"] = []string{"a", "o"}
+		keys["global_search"] = []string{"F"}
+	case "emacs":
+		keys["up"] = []string{"up", "ctrl+p"}
+		keys["down"] = []string{"down", "ctrl+n"}
+		keys["page_up"] = []string{"pgup", "alt+v"}
+		keys["page_down"] = []string{"pgdown", "ctrl+v"}
+		keys["half_page_up"] = nil
+		keys["half_page_down"] = nil
+		keys["top"] = []string{"home", "alt+<"}
+		keys["bottom"] = []string{"end", "alt+>"}
+		keys["delete"] = []string{"x", "ctrl+d"}
+		keys["search"] = []string{"/", "ctrl+s"}
+		keys["quit"] = []string{"q", "ctrl+x"}
+	}
+	return keys
+}
+
+// presetKeys is a preset with [keys] overrides applied on top.
+func presetKeys(preset string, overrides map[string][]string) map[string][]string {
+	keys := keyPreset(preset)
+	for action, k := range overrides {
+		keys[action] = k
+	}
+	return keys
+}
+
+// validateKeys checks the [keys] overrides name real actions and that, with the preset, no key
+// ends up bound to two actions.
+func validateKeys(preset string, overrides map[string][]string) error {
+	known := map[string]bool{}
+	for _, a := range keyActions {
+		known[a.name] = true
+	}
+	for action := range overrides {
+		if !known[action] {
+			return fmt.Errorf("keys.%s isn't an action (available: %s)", action, strings.Join(keyNames(), ", "))
+		}
+	}
+
+	keys := presetKeys(preset, overrides)
+	owner := map[string]string{}
+	for _, a := range keyActions {
+		for _, k := range keys[a.name] {
+			if k == "" {
+				return fmt.Errorf("keys.%s has an empty key", a.name)
+			}
+			if other, ok := owner[k]; ok {
+				return fmt.Errorf("key %q is bound to both %s and %s", k, other, a.name)
+			}
+			owner[k] = a.name
+		}
+	}
+	for _, d := range "123456789" {
+		if action, ok := owner[string(d)]; ok {
+			return fmt.Errorf("key %q is bound to %s but 1-9 jump to a task", string(d), action)
+		}
+	}
+	return nil
+}
+
+// newKeyMap builds the key bindings for a preset with overrides. Both have been validated.
+func newKeyMap(preset string, overrides map[string][]string) keyMap {
+	keys := presetKeys(preset, overrides)
+	desc := map[string]string{}
+	for _, a := range keyActions {
+		desc[a.name] = a.desc
+	}
+	bind := func(action string) key.Binding {
+		if len(keys[action]) == 0 {
+			return key.NewBinding(key.WithDisabled())
+		}
+		return key.NewBinding(key.WithKeys(keys[action]...), key.WithHelp(keyHelp(keys[action]), desc[action]))
+	}
+
+	return keyMap{
+		Up:            bind("up"),
+		Down:          bind("down"),
+		PageUp:        bind("page_up"),
+		PageDown:      bind("page_down"),
+		HalfPageUp:    bind("half_page_up"),
+		HalfPageDown:  bind("half_page_down"),
+		Top:           bind("top"),
+		Bottom:        bind("bottom"),
+		Add:           bind("add"),
+		Start:         bind("start"),
+		Focus:         bind("focus"),
+		Done:          bind("done"),
+		Blocked:       bind("blocked"),
+		Delegated:     bind("delegated"),
+		Cancel:        bind("cancel"),
+		Edit:          bind("edit"),
+		Delete:        bind("delete"),
+		Carry:         bind("carry"),
+		Import:        bind("import"),
+		ViewDate:      bind("view_date"),
+		History:       bind("history"),
+		Stats:         bind("stats"),
+		SwitchContext: bind("switch_context"),
+		Search:        bind("search"),
+		GlobalSearch:  bind("global_search"),
+		Inbox:         bind("inbox"),
+		Jump:          key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "jump")),
+		Help:          bind("help"),
+		Quit:          bind("quit"),
+	}
+}
+
+// keyHelp is how keys are shown in help, e.g. "e/↵" or "↑/k".
+func keyHelp(keys []string) string {
+	names := map[string]string{"up": "↑", "down": "↓", "left": "←", "right": "→", "enter": "↵", " ": "space", "pgup": "pgup", "pgdown": "pgdn"}
+	shown := make([]string, len(keys))
+	for i, k := range keys {
+		if name, ok := names[k]; ok {
+			k = name
+		}
+		shown[i] = k
+	}
+	return strings.Join(shown, "/")
+}
+
+// tableKeyMap gives the table component the navigation bindings.
+func (k keyMap) tableKeyMap() table.KeyMap {
+	return table.KeyMap{
+		LineUp:       k.Up,
+		LineDown:     k.Down,
+		PageUp:       k.PageUp,
+		PageDown:     k.PageDown,
+		HalfPageUp:   k.HalfPageUp,
+		HalfPageDown: k.HalfPageDown,
+		GotoTop:      k.Top,
+		GotoBottom:   k.Bottom,
+	}
+}
+
+// ShortHelp is the one-line help under the table.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Add, k.Start, k.Done, k.Edit, k.Delete, k.Carry, k.Search, k.GlobalSearch, k.SwitchContext, k.Help, k.Quit}
+}
+
+// FullHelp is the ? overlay, one column per group of actions.
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.HalfPageUp, k.HalfPageDown, k.Top, k.Bottom, k.Jump},
+		{k.Add, k.Edit, k.Delete, k.Start, k.Focus, k.Done, k.Blocked, k.Delegated, k.Cancel},
+		{k.Carry, k.Import, k.ViewDate, k.Search, k.GlobalSearch, k.History, k.Stats, k.SwitchContext, k.Inbox},
+		{k.Help, k.Quit},
+	}
+}
+
+// emptyDayHelp is the help line when there are no tasks to act on.
+func (k keyMap) emptyDayHelp(canImport bool) []key.Binding {
+	bindings := []key.Binding{k.Add}
+	if canImport {
+		bindings = append(bindings, k.Import)
+	}
+	return append(bindings, k.ViewDate, k.GlobalSearch, k.SwitchContext, k.Inbox, k.Help, k.Quit)
+}
+
+// newHelp returns a help view styled to match the rest of the TUI.
+func newHelp() help.Model {
+	h := help.New()
+	h.ShortSeparator = " · "
+	h.Styles.ShortKey = helpStyle
+	h.Styles.ShortDesc = helpStyle
+	h.Styles.ShortSeparator = helpStyle
+	h.Styles.Ellipsis = helpStyle
+	h.Styles.FullKey = helpStyle.Bold(true)
+	h.Styles.FullDesc = helpStyle
+	h.Styles.FullSeparator = helpStyle
+	return h
+}
+
+// keyNames is the sorted list of action names, for error messages and docs.
+func keyNames() []string {
+	names := make([]string, len(keyActions))
+	for i, a := range keyActions {
+		names[i] = a.name
+	}
+	sort.Strings(names)
+	return names
+}
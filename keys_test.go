@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeyPresetsValid(t *testing.T) {
+	for _, name := range keymapNames {
+		if err := validateKeys(name, nil); err != nil {
+			t.Errorf("preset %q: %v", name, err)
+		}
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string][]string
+		want      string
+	}{
+		{"remap", "default", map[string][]string{"done": {"D"}, "delete": {"d"}}, ""},
+		{"unbind", "default", map[string][]string{"stats": {}}, ""},
+		{"unknown action", "default", map[string][]string{"explode": {"e"}}, "keys.explode isn't an action"},
+		{"conflict", "default", map[string][]string{"add": {"d"}}, `key "d" is bound to both add and done`},
+		{"conflict with preset", "emacs", map[string][]string{"half_page_down": {"ctrl+d"}}, `"ctrl+d"`},
+		{"digit", "default", map[string][]string{"add": {"1"}}, "1-9 jump"},
+		{"empty key", "default", map[string][]string{"add": {""}}, "empty key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeys(tt.preset, tt.overrides)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	k := newKeyMap("emacs", map[string][]string{"done": {"D", "enter"}, "edit": {"e"}, "stats": {}})
+
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlN}, k.Down) {
+		t.Error("expected ctrl+n to move down in the emacs preset")
+	}
+	if got := k.Done.Help().Key; got != "D/↵" {
+		t.Errorf("done help key = %q, want D/↵", got)
+	}
+	if k.Stats.Enabled() {
+		t.Error("expected an unbound action to be disabled")
+	}
+	if k.HalfPageDown.Enabled() {
+		t.Error("expected the emacs preset to leave half-page down unbound")
+	}
+}
+
+func TestLoadConfigKeys(t *testing.T) {
+	cfg, err := loadConfig(writeConfig(t, `
+keymap = "vim"
+
+[keys]
+done = ["D"]
+delete = ["d"]
+`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keymap != "vim" || strings.Join(cfg.Keys["done"], ",") != "D" {
+		t.Errorf("got %+v", cfg)
+	}
+
+	for _, content := range []string{`keymap = "nano"`, "[keys]\nadd = [\"d\"]"} {
+		if _, err := loadConfig(writeConfig(t, content), true); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
+
+func TestTableKeysFollowKeymap(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-01-15", "First", PriorityA, "1h", "default")
+	s.AddTask("2025-01-15", "Second", PriorityB, "1h", "default")
+
+	cfg := defaultConfig()
+	cfg.Keymap = "vim"
+	cfg.Keys = map[string][]string{"done": {"D"}}
+	useConfig(t, cfg)
+
+	m := newModel(s, "2025-01-15", "default", false)
+	press := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "esc" {
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		m.Update(msg)
+	}
+
+	press("d") // no longer bound to done
+	if tasks, _ := s.GetTasksForDate("2025-01-15", "default"); tasks[0].Status != StatusTodo {
+		t.Error("d shouldn't mark a task done once remapped")
+	}
+	press("D")
+	if tasks, _ := s.GetTasksForDate("2025-01-15", "default"); tasks[0].Status != StatusDone {
+		t.Error("D should mark the selected task done")
+	}
+
+	press("?")
+	if m.mode != modeHelp {
+		t.Fatalf("expected ? to open help, mode = %v", m.mode)
+	}
+	if view := m.View(); !strings.Contains(view, "a/o") || !strings.Contains(view, "D") {
+		t.Errorf("help overlay should list the active bindings:\n%s", view)
+	}
+	press("esc")
+	if m.mode != modeTable {
+		t.Errorf("expected esc to close help, mode = %v", m.mode)
+	}
+
+	press("o")
+	if m.mode != modeAdd {
+		t.Errorf("expected o to add a task in the vim preset, mode = %v", m.mode)
+	}
+}
+
+func TestScreensCloseWithTheirRemappedKey(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-01-15", "First", PriorityA, "1h", "default")
+
+	cfg := defaultConfig()
+	cfg.Keys = map[string][]string{"history": {"H"}, "stats": {"T"}, "half_page_up": {"h"}}
+	useConfig(t, cfg)
+
+	m := newModel(s, "2025-01-15", "default", false)
+	press := func(k string) { m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+
+	for _, screen := range []struct {
+		open, old string
+		mode      mode
+	}{{"H", "h", modeHistory}, {"T", "S", modeStats}} {
+		press(screen.open)
+		if m.mode != screen.mode {
+			t.Fatalf("expected %s to open mode %v, got %v", screen.open, screen.mode, m.mode)
+		}
+		press(screen.old)
+		if m.mode != screen.mode {
+			t.Errorf("%s is no longer bound, so it shouldn't close mode %v", screen.old, screen.mode)
+		}
+		press(screen.open)
+		if m.mode != modeTable {
+			t.Errorf("expected %s to close mode %v, got %v", screen.open, screen.mode, m.mode)
+		}
+	}
+}
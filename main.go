@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -10,73 +12,339 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// dbFlag is the --db path, "" to use $GTD_DB or the default location.
+var dbFlag string
+
 func main() {
-	date, printMode, context, err := parseArgs(os.Args[1:])
+	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+		runComplete(os.Args[2:])
+		return
+	}
+
+	args, err := setupConfig(os.Args[1:])
+	if err == nil {
+		dbFlag, args, err = takeGlobalFlag(args, "--db")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		if cmd := rootCommand.sub(args[0]); cmd != nil {
+			if wantsHelp(args[1:]) {
+				writeHelp(os.Stdout, helpTarget(cmd, args[1:]))
+				return
+			}
+			runCommand(cmd, args[1:])
+			return
+		}
+	}
+	if wantsHelp(args) {
+		writeHelp(os.Stdout, rootCommand)
+		return
+	}
+
+	opts, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\nUsage: gtd [dd/mm/yyyy] [--print] [--context <name>]\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n%s\n", err, rootCommand.usage())
 		os.Exit(1)
 	}
 
-	store, err := NewStore()
+	store, err := openStore()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
 		os.Exit(1)
 	}
 	defer store.Close()
 
-	if printMode {
-		if err := printTasks(store, date, context); err != nil {
+	if opts.Print && !opts.AllContexts {
+		if warning, err := store.checkContext(opts.Context); err == nil && warning != "" {
+			fmt.Fprintln(os.Stderr, "Warning: "+warning)
+		}
+	}
+
+	filter, _ := parseQuery(opts.Filter) // already checked by parseArgs
+
+	if opts.DueQuery {
+		if err := printDueTasks(store, opts.Context, opts.DueWithinDays, time.Now().Format("2006-01-02"), filter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Print {
+		printFn := func() error { return printTasks(store, opts.Date, opts.Context, filter) }
+		if opts.AllContexts {
+			printFn = func() error { return printTasksAllContexts(store, opts.Date, filter) }
+		}
+		if err := printFn(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	p := tea.NewProgram(newModel(store, date, context), tea.WithAltScreen())
+	if !confirmContext(store, opts.Context, opts.AllContexts) {
+		return
+	}
+
+	m := newModel(store, opts.Date, opts.Context, opts.AllContexts)
+	if opts.Filter != "" {
+		m.filterText = opts.Filter
+		m.applyFilter()
+	}
+	store.hooks.holdWarnings() // until the TUI has given the terminal back
+	if config.AutoRollover && !opts.AllContexts && opts.Date == time.Now().Format("2006-01-02") {
+		from, n, err := rollOver(store, opts.Date, opts.Context)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if n > 0 {
+			m.refreshTasks()
+			fromDate, _ := time.Parse("2006-01-02", from)
+			m.status = fmt.Sprintf("Carried %d task(s) over from %s.", n, fromDate.Format(config.dateLayout()))
+		}
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-// parseArgs extracts the date, --print flag, and --context from command-line arguments.
-// Flags and date can appear in any order.
-func parseArgs(args []string) (date string, printMode bool, context string, err error) {
-	date = time.Now().Format("2006-01-02")
-	context = "default"
+// setupConfig loads the config file named by --config, or the default one, and returns the
+// command line with --config removed.
+func setupConfig(args []string) ([]string, error) {
+	path, rest, err := takeGlobalFlag(args, "--config")
+	if err != nil {
+		return nil, err
+	}
+	explicit := path != ""
+	if !explicit {
+		if path, err = defaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := loadConfig(path, explicit)
+	if err != nil {
+		return nil, err
+	}
+	config, configPath = cfg, path
+	applyTheme(configuredTheme(config))
+	return rest, nil
+}
+
+// openStore opens the database chosen by --db, $GTD_DB or the default location, saying so
+// when an old database has just been moved to the default location.
+func openStore() (*Store, error) {
+	path, movedFrom, err := resolveDBPath(dbFlag)
+	if err != nil {
+		return nil, err
+	}
+	if movedFrom != "" {
+		fmt.Fprintf(os.Stderr, "Moved database from %s to %s\n", movedFrom, path)
+	}
+	store, err := NewStore(path)
+	if err != nil {
+		return nil, err
+	}
+	// A failed daily snapshot shouldn't stop gtd from starting
+	if err := store.DailySnapshot(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: daily snapshot failed: %v\n", err)
+	}
+	store.hooks = newHookRunner(hooksDir(configPath), config.Hooks, config.hookTimeout(), path)
+	return store, nil
+}
+
+// rollOver carries open tasks from the most recent earlier day with any to today, for the
+// auto_rollover setting. It returns the day they came from and how many were carried; tasks
+// already carried are skipped, so running it again does nothing.
+func rollOver(store *Store, today, context string) (string, int, error) {
+	from, err := store.GetLatestDateWithIncompleteTasks(today, context)
+	if err != nil || from == "" {
+		return "", 0, err
+	}
+	tasks, err := store.GetCarryOverCandidates(from, today, context)
+	if err != nil || len(tasks) == 0 {
+		return from, 0, err
+	}
+	if err := store.CarryOverTasks(tasks, today, context); err != nil {
+		return from, 0, err
+	}
+	return from, len(tasks), nil
+}
+
+// confirmContext warns about an unknown or archived context before the TUI opens on what
+// would otherwise be a silently empty list, and asks whether to carry on.
+func confirmContext(store *Store, context string, allContexts bool) bool {
+	if allContexts {
+		return true
+	}
+	warning, err := store.checkContext(context)
+	if err != nil || warning == "" {
+		return true
+	}
+
+	fmt.Fprintf(os.Stderr, "%s Open it anyway? [y/N] ", warning)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// runCommand runs a subcommand, opening the database first unless it doesn't need one.
+func runCommand(cmd *command, args []string) {
+	if cmd.noStore {
+		if err := cmd.run(nil, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	if err := cmd.run(store, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// options holds everything parsed from the command line.
+type options struct {
+	Date          string // yyyy-mm-dd
+	Print         bool
+	Context       string
+	DueQuery      bool // --due-within was given
+	DueWithinDays int
+	AllContexts   bool
+	Filter        string // query syntax, see parseQuery
+}
+
+// parseArgs extracts the date, --print flag, --context, --due-within, --all-contexts and --filter from
+// command-line arguments. Flags and date can appear in any order.
+func parseArgs(args []string) (options, error) {
+	opts := options{
+		Date:    time.Now().Format("2006-01-02"),
+		Context: config.DefaultContext,
+	}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		if arg == "--print" {
-			printMode = true
+			opts.Print = true
+			continue
+		}
+		if arg == "--all-contexts" {
+			opts.AllContexts = true
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return options{}, err
+			}
+			opts.Context = value
+			i = next
 			continue
 		}
-		if arg == "--context" {
-			if i+1 >= len(args) {
-				return "", false, "", fmt.Errorf("--context requires a value")
+		if value, next, ok, err := takeFlag(args, i, "--filter"); ok {
+			if err != nil {
+				return options{}, err
+			}
+			if _, err := parseQuery(value); err != nil {
+				return options{}, fmt.Errorf("--filter: %w", err)
 			}
-			i++
-			context = args[i]
+			opts.Filter = value
+			i = next
 			continue
 		}
-		if strings.HasPrefix(arg, "--context=") {
-			context = strings.TrimPrefix(arg, "--context=")
-			if context == "" {
-				return "", false, "", fmt.Errorf("--context requires a value")
+		if value, next, ok, err := takeFlag(args, i, "--due-within"); ok {
+			if err != nil {
+				return options{}, err
 			}
+			i = next
+			days, err := parseDayCount(value)
+			if err != nil {
+				return options{}, err
+			}
+			opts.DueQuery = true
+			opts.DueWithinDays = days
 			continue
 		}
-		t, parseErr := time.Parse("02/01/2006", arg)
-		if parseErr != nil {
-			return "", false, "", fmt.Errorf("expected dd/mm/yyyy, got %q", arg)
+		date, err := parseInputDate(arg)
+		if err != nil {
+			return options{}, err
+		}
+		opts.Date = date
+	}
+
+	if opts.DueQuery && !opts.Print {
+		return options{}, fmt.Errorf("--due-within requires --print")
+	}
+
+	return opts, nil
+}
+
+// takeFlag reports whether args[i] is the named flag, given as "--name value" or "--name=value".
+// It returns the flag's value and the index of the last argument consumed.
+func takeFlag(args []string, i int, name string) (value string, last int, ok bool, err error) {
+	arg := args[i]
+	switch {
+	case arg == name:
+		if i+1 >= len(args) {
+			return "", i, true, fmt.Errorf("%s requires a value", name)
+		}
+		return args[i+1], i + 1, true, nil
+	case strings.HasPrefix(arg, name+"="):
+		value = strings.TrimPrefix(arg, name+"=")
+		if value == "" {
+			return "", i, true, fmt.Errorf("%s requires a value", name)
 		}
-		date = t.Format("2006-01-02")
+		return value, i, true, nil
+	}
+	return "", i, false, nil
+}
+
+// parseInputDate converts a user-entered date in the configured format (dd/mm/yyyy by default)
+// to yyyy-mm-dd.
+func parseInputDate(s string) (string, error) {
+	t, err := time.Parse(config.dateLayout(), s)
+	if err != nil {
+		return "", fmt.Errorf("expected %s, got %q", config.DateFormat, s)
 	}
+	return t.Format("2006-01-02"), nil
+}
 
-	return date, printMode, context, nil
+// parseDayCount parses a span like "3d" or "2w" (a bare number means days) into days.
+func parseDayCount(s string) (int, error) {
+	unit := 1
+	num := s
+	switch {
+	case strings.HasSuffix(s, "d"):
+		num = strings.TrimSuffix(s, "d")
+	case strings.HasSuffix(s, "w"):
+		num = strings.TrimSuffix(s, "w")
+		unit = 7
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a span like 3d or 2w, got %q", s)
+	}
+	return n * unit, nil
 }
 
-func printTasks(store *Store, date, context string) error {
+// printTasks prints a day's tasks in a context. Tasks not matching filter are left out but keep
+// their numbers, as in the TUI.
+func printTasks(store *Store, date, context string, filter query) error {
 	tasks, err := store.GetTasksForDate(date, context)
 	if err != nil {
 		return err
@@ -91,20 +359,64 @@ func printTasks(store *Store, date, context string) error {
 		return nil
 	}
 
+	today := time.Now().Format("2006-01-02")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "#\tTask\tPriority\tTime\tStatus")
+	fmt.Fprintln(w, "#\tTask\tPriority\tTime\tDue\tStatus")
 	for i, t := range tasks {
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, t.DisplayDescription(), t.Priority, t.TimeEstimate, t.Status.PrintLabel())
+		if !filter.Match(t) {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, t.DisplayDescription(), t.Priority, t.TimeEstimate, t.DueDisplay(today), t.Status.PrintLabel())
 	}
 	w.Flush()
 
-	completed := len(filterTasks(tasks, func(t Task) bool { return t.Status == StatusDone }))
-	inProgress := len(filterTasks(tasks, func(t Task) bool { return t.Status == StatusInProgress }))
-	summary := fmt.Sprintf("\n%d/%d tasks completed", completed, len(tasks))
-	if inProgress > 0 {
-		summary += fmt.Sprintf(", %d in progress", inProgress)
+	fmt.Println("\n" + filteredSummary(tasks, filter))
+
+	return nil
+}
+
+// filteredSummary is completionSummary for the whole day, noting how many tasks a filter showed.
+func filteredSummary(tasks []Task, filter query) string {
+	summary := completionSummary(tasks)
+	if !filter.isEmpty() {
+		summary += fmt.Sprintf(" (showing %d)", len(filterTasks(tasks, filter.Match)))
 	}
-	fmt.Println(summary)
+	return summary
+}
+
+// printDueTasks lists unfinished tasks in a context whose deadline falls within the given
+// number of days of today (including anything already overdue), across all planned dates.
+func printDueTasks(store *Store, context string, days int, today string, filter query) error {
+	t, _ := time.Parse("2006-01-02", today)
+	dueBy := t.AddDate(0, 0, days).Format("2006-01-02")
+
+	tasks, err := store.GetTasksDueBy(dueBy, context)
+	if err != nil {
+		return err
+	}
+	tasks = filterTasks(tasks, filter.Match)
+
+	fmt.Printf("Due by %s\n\n", formatHeading(dueBy))
+
+	if len(tasks) == 0 {
+		fmt.Println("No tasks due.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Due\tPlanned\tTask\tPriority\tTime\tStatus")
+	for _, task := range tasks {
+		due, _ := time.Parse("2006-01-02", task.DueDate)
+		planned, _ := time.Parse("2006-01-02", task.Date)
+		dueLabel := due.Format(config.dateLayout())
+		if task.IsOverdue(today) {
+			dueLabel += " (overdue)"
+		} else if task.IsDueOn(today) {
+			dueLabel += " (today)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", dueLabel, planned.Format(config.dateLayout()), task.Description, task.Priority, task.TimeEstimate, task.Status.PrintLabel())
+	}
+	w.Flush()
 
 	return nil
 }
@@ -10,139 +10,214 @@ import (
 )
 
 func TestParseArgsDefaults(t *testing.T) {
-	date, printMode, context, err := parseArgs([]string{})
+	opts, err := parseArgs([]string{})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if printMode {
+	if opts.Print {
 		t.Error("expected printMode=false with no args")
 	}
 	today := time.Now().Format("2006-01-02")
-	if date != today {
-		t.Errorf("expected today %q, got %q", today, date)
+	if opts.Date != today {
+		t.Errorf("expected today %q, got %q", today, opts.Date)
 	}
-	if context != "default" {
-		t.Errorf("expected context %q, got %q", "default", context)
+	if opts.Context != "default" {
+		t.Errorf("expected context %q, got %q", "default", opts.Context)
 	}
 }
 
 func TestParseArgsDateOnly(t *testing.T) {
-	date, printMode, context, err := parseArgs([]string{"25/12/2025"})
+	opts, err := parseArgs([]string{"25/12/2025"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if printMode {
+	if opts.Print {
 		t.Error("expected printMode=false")
 	}
-	if date != "2025-12-25" {
-		t.Errorf("expected 2025-12-25, got %q", date)
+	if opts.Date != "2025-12-25" {
+		t.Errorf("expected 2025-12-25, got %q", opts.Date)
 	}
-	if context != "default" {
-		t.Errorf("expected context %q, got %q", "default", context)
+	if opts.Context != "default" {
+		t.Errorf("expected context %q, got %q", "default", opts.Context)
 	}
 }
 
 func TestParseArgsPrintOnly(t *testing.T) {
-	date, printMode, _, err := parseArgs([]string{"--print"})
+	opts, err := parseArgs([]string{"--print"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !printMode {
+	if !opts.Print {
 		t.Error("expected printMode=true")
 	}
 	today := time.Now().Format("2006-01-02")
-	if date != today {
-		t.Errorf("expected today %q, got %q", today, date)
+	if opts.Date != today {
+		t.Errorf("expected today %q, got %q", today, opts.Date)
 	}
 }
 
 func TestParseArgsDateThenPrint(t *testing.T) {
-	date, printMode, _, err := parseArgs([]string{"14/02/2026", "--print"})
+	opts, err := parseArgs([]string{"14/02/2026", "--print"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !printMode {
+	if !opts.Print {
 		t.Error("expected printMode=true")
 	}
-	if date != "2026-02-14" {
-		t.Errorf("expected 2026-02-14, got %q", date)
+	if opts.Date != "2026-02-14" {
+		t.Errorf("expected 2026-02-14, got %q", opts.Date)
 	}
 }
 
 func TestParseArgsPrintThenDate(t *testing.T) {
-	date, printMode, _, err := parseArgs([]string{"--print", "14/02/2026"})
+	opts, err := parseArgs([]string{"--print", "14/02/2026"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !printMode {
+	if !opts.Print {
 		t.Error("expected printMode=true")
 	}
-	if date != "2026-02-14" {
-		t.Errorf("expected 2026-02-14, got %q", date)
+	if opts.Date != "2026-02-14" {
+		t.Errorf("expected 2026-02-14, got %q", opts.Date)
 	}
 }
 
 func TestParseArgsInvalidDate(t *testing.T) {
-	_, _, _, err := parseArgs([]string{"not-a-date"})
+	_, err := parseArgs([]string{"not-a-date"})
 	if err == nil {
 		t.Error("expected error for invalid date")
 	}
 }
 
 func TestParseArgsContextSpace(t *testing.T) {
-	date, printMode, context, err := parseArgs([]string{"--context", "work"})
+	opts, err := parseArgs([]string{"--context", "work"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if printMode {
+	if opts.Print {
 		t.Error("expected printMode=false")
 	}
 	today := time.Now().Format("2006-01-02")
-	if date != today {
-		t.Errorf("expected today %q, got %q", today, date)
+	if opts.Date != today {
+		t.Errorf("expected today %q, got %q", today, opts.Date)
 	}
-	if context != "work" {
-		t.Errorf("expected context %q, got %q", "work", context)
+	if opts.Context != "work" {
+		t.Errorf("expected context %q, got %q", "work", opts.Context)
 	}
 }
 
 func TestParseArgsContextEquals(t *testing.T) {
-	_, _, context, err := parseArgs([]string{"--context=personal"})
+	opts, err := parseArgs([]string{"--context=personal"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if context != "personal" {
-		t.Errorf("expected context %q, got %q", "personal", context)
+	if opts.Context != "personal" {
+		t.Errorf("expected context %q, got %q", "personal", opts.Context)
 	}
 }
 
 func TestParseArgsContextMissingValue(t *testing.T) {
-	_, _, _, err := parseArgs([]string{"--context"})
+	_, err := parseArgs([]string{"--context"})
 	if err == nil {
 		t.Error("expected error for --context without value")
 	}
 }
 
 func TestParseArgsContextEmptyEquals(t *testing.T) {
-	_, _, _, err := parseArgs([]string{"--context="})
+	_, err := parseArgs([]string{"--context="})
 	if err == nil {
 		t.Error("expected error for --context= with empty value")
 	}
 }
 
 func TestParseArgsAllFlags(t *testing.T) {
-	date, printMode, context, err := parseArgs([]string{"14/02/2026", "--print", "--context", "work"})
+	opts, err := parseArgs([]string{"14/02/2026", "--print", "--context", "work"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !printMode {
+	if !opts.Print {
 		t.Error("expected printMode=true")
 	}
-	if date != "2026-02-14" {
-		t.Errorf("expected 2026-02-14, got %q", date)
+	if opts.Date != "2026-02-14" {
+		t.Errorf("expected 2026-02-14, got %q", opts.Date)
 	}
-	if context != "work" {
-		t.Errorf("expected context %q, got %q", "work", context)
+	if opts.Context != "work" {
+		t.Errorf("expected context %q, got %q", "work", opts.Context)
+	}
+}
+
+func TestParseArgsAllContexts(t *testing.T) {
+	opts, err := parseArgs([]string{"--print", "--all-contexts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.AllContexts {
+		t.Error("expected AllContexts=true")
+	}
+}
+
+func TestParseArgsDueWithin(t *testing.T) {
+	opts, err := parseArgs([]string{"--print", "--due-within", "3d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.DueQuery || opts.DueWithinDays != 3 {
+		t.Errorf("expected due query of 3 days, got %v/%d", opts.DueQuery, opts.DueWithinDays)
+	}
+
+	opts, err = parseArgs([]string{"--due-within=2w", "--print"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.DueWithinDays != 14 {
+		t.Errorf("expected 14 days, got %d", opts.DueWithinDays)
+	}
+}
+
+func TestParseArgsDueWithinRequiresPrint(t *testing.T) {
+	_, err := parseArgs([]string{"--due-within", "3d"})
+	if err == nil {
+		t.Error("expected error for --due-within without --print")
+	}
+}
+
+func TestParseArgsFilter(t *testing.T) {
+	opts, err := parseArgs([]string{"--print", "--filter", "p:A status:open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Filter != "p:A status:open" {
+		t.Errorf("expected filter %q, got %q", "p:A status:open", opts.Filter)
+	}
+
+	if _, err := parseArgs([]string{"--print", "--filter=status:sleeping"}); err == nil {
+		t.Error("expected error for an invalid --filter query")
+	}
+}
+
+func TestParseDayCount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"3d", 3, false},
+		{"0d", 0, false},
+		{"2w", 14, false},
+		{"5", 5, false},
+		{"", 0, true},
+		{"3h", 0, true},
+		{"-1d", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDayCount(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDayCount(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDayCount(%q) = %d, want %d", tt.in, got, tt.want)
+		}
 	}
 }
 
@@ -198,23 +273,68 @@ func TestPrintTasksWithInProgress(t *testing.T) {
 	}
 }
 
+func TestPrintDueTasks(t *testing.T) {
+	s := newTestStore(t)
+	s.CreateTask(Task{Date: "2025-06-01", Description: "Renew cert", Priority: PriorityA, TimeEstimate: "1h", DueDate: "2025-06-03", Context: "default"})
+	s.CreateTask(Task{Date: "2025-06-01", Description: "Overdue report", Priority: PriorityB, TimeEstimate: "1h", DueDate: "2025-05-30", Context: "default"})
+	s.CreateTask(Task{Date: "2025-06-01", Description: "Far off", Priority: PriorityB, TimeEstimate: "1h", DueDate: "2025-07-01", Context: "default"})
+
+	output := captureOutput(t, func() error { return printDueTasks(s, "default", 3, "2025-06-01", query{}) })
+
+	if !strings.Contains(output, "Renew cert") {
+		t.Errorf("expected 'Renew cert' in output, got:\n%s", output)
+	}
+	if !strings.Contains(output, "(overdue)") {
+		t.Errorf("expected overdue marker in output, got:\n%s", output)
+	}
+	if strings.Contains(output, "Far off") {
+		t.Errorf("did not expect 'Far off' in output, got:\n%s", output)
+	}
+}
+
 func capturePrintTasks(t *testing.T, store *Store, date, context string) string {
 	t.Helper()
+	return captureOutput(t, func() error { return printTasks(store, date, context, query{}) })
+}
+
+// captureOutput runs fn with os.Stdout redirected and returns what it printed.
+func captureOutput(t *testing.T, fn func() error) string {
+	t.Helper()
 
 	old := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := printTasks(store, date, context)
+	err := fn()
 
 	w.Close()
 	os.Stdout = old
 
 	if err != nil {
-		t.Fatalf("printTasks returned error: %v", err)
+		t.Fatalf("print returned error: %v", err)
 	}
 
 	var buf bytes.Buffer
 	io.Copy(&buf, r)
 	return buf.String()
 }
+
+func TestPrintTasksFiltered(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Fix server #ops", PriorityA, "2h", "default")
+	s.AddTask("2025-06-01", "Buy milk", PriorityB, "30m", "default")
+	s.AddTask("2025-06-01", "Patch firewall #ops", PriorityC, "1h", "default")
+
+	filter, _ := parseQuery("tag:ops -p:A")
+	output := captureOutput(t, func() error { return printTasks(s, "2025-06-01", "default", filter) })
+
+	if strings.Contains(output, "Fix server") || strings.Contains(output, "Buy milk") {
+		t.Errorf("expected only the matching task, got:\n%s", output)
+	}
+	if !strings.Contains(output, "3  Patch firewall #ops") {
+		t.Errorf("expected the matching task to keep its number, got:\n%s", output)
+	}
+	if !strings.Contains(output, "0/3 tasks completed (showing 1)") {
+		t.Errorf("expected the summary to note the filter, got:\n%s", output)
+	}
+}
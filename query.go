@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// query is a parsed filter such as `p:A status:wip -tag:home backup`. Every term must match
+// (AND is implicit, and the word AND is accepted). Words without a field are fuzzy-matched
+// against the description.
+type query struct {
+	terms []queryTerm
+}
+
+type queryTerm struct {
+	negate  bool
+	match   func(Task) bool
+	pattern string // free-text pattern for fuzzy matching and highlighting; "" for field terms
+}
+
+// queryFields are the field names parseQuery understands, for error messages.
+var queryFields = []string{"p", "status", "est", "carried", "tag", "context"}
+
+// parseQuery parses the filter syntax:
+//
+//	p:A  p:A,B  p:<=B      priority
+//	status:wip             todo, wip, done, blocked, delegated, cancelled, open or closed
+//	est:>1h  est:<=30m     time estimate
+//	carried:yes            carried over from an earlier day
+//	tag:home               #home in the description
+//	context:work           context, for the all-contexts view
+//
+// A leading - or ! (or the word NOT) negates the next term. Double quotes group words.
+func parseQuery(s string) (query, error) {
+	var q query
+	words, err := splitQuery(s)
+	if err != nil {
+		return query{}, err
+	}
+
+	negateNext := false
+	for _, word := range words {
+		switch strings.ToUpper(word) {
+		case "AND":
+			continue
+		case "NOT":
+			negateNext = !negateNext
+			continue
+		case "OR":
+			return query{}, fmt.Errorf("OR isn't supported; terms are always ANDed")
+		}
+
+		term := queryTerm{negate: negateNext}
+		negateNext = false
+		if len(word) > 1 && (word[0] == '-' || word[0] == '!') {
+			term.negate = !term.negate
+			word = word[1:]
+		}
+
+		field, value, isField := strings.Cut(word, ":")
+		if isField && isQueryField(field) {
+			value = strings.Trim(value, `"`)
+			if term.match, err = fieldMatcher(strings.ToLower(field), value); err != nil {
+				return query{}, err
+			}
+		} else {
+			pattern := strings.Trim(word, `"`)
+			if term.negate {
+				// Fuzzy negation would exclude far too much, so "-word" means "doesn't contain word"
+				needle := strings.ToLower(pattern)
+				term.match = func(t Task) bool { return strings.Contains(strings.ToLower(t.Description), needle) }
+			} else {
+				term.pattern = pattern
+				term.match = func(t Task) bool {
+					_, ok := fuzzyMatch(t.Description, pattern)
+					return ok
+				}
+			}
+		}
+		q.terms = append(q.terms, term)
+	}
+	if negateNext {
+		return query{}, fmt.Errorf("NOT needs a term after it")
+	}
+	return q, nil
+}
+
+// splitQuery splits on spaces, keeping double-quoted runs together (quotes are kept).
+func splitQuery(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			word.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unclosed quote")
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func isQueryField(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range queryFields {
+		if f == name {
+			return true
+		}
+	}
+	return name == "priority"
+}
+
+func fieldMatcher(field, value string) (func(Task) bool, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%s: needs a value", field)
+	}
+
+	switch field {
+	case "p", "priority":
+		op, v := splitComparison(value)
+		var allowed []Priority
+		for _, p := range strings.Split(strings.ToUpper(v), ",") {
+			switch Priority(p) {
+			case PriorityA, PriorityB, PriorityC, PriorityD:
+				allowed = append(allowed, Priority(p))
+			default:
+				return nil, fmt.Errorf("p: expects A, B, C or D, got %q", p)
+			}
+		}
+		if op != "=" {
+			if len(allowed) != 1 {
+				return nil, fmt.Errorf("p:%s compares against a single priority", op)
+			}
+			want := allowed[0]
+			// A is the highest priority, so p:<B means "more important than B", i.e. A
+			return func(t Task) bool { return compare(op, strings.Compare(string(t.Priority), string(want))) }, nil
+		}
+		return func(t Task) bool {
+			for _, p := range allowed {
+				if t.Priority == p {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case "status":
+		match, ok := statusMatchers[strings.ToLower(value)]
+		if !ok {
+			return nil, fmt.Errorf("status: expects todo, wip, done, blocked, delegated, cancelled, open or closed, got %q", value)
+		}
+		return func(t Task) bool { return match(t.Status) }, nil
+
+	case "est":
+		op, v := splitComparison(value)
+		want, ok := parseEstimate(v)
+		if !ok {
+			return nil, fmt.Errorf("est: expects a duration such as 30m, 2h or 1d, got %q", v)
+		}
+		return func(t Task) bool {
+			got, ok := parseEstimate(t.TimeEstimate)
+			return ok && compare(op, compareDurations(got, want))
+		}, nil
+
+	case "carried":
+		var want bool
+		switch strings.ToLower(value) {
+		case "yes", "y", "true":
+			want = true
+		case "no", "n", "false":
+		default:
+			return nil, fmt.Errorf("carried: expects yes or no, got %q", value)
+		}
+		return func(t Task) bool { return t.WasCarriedOver() == want }, nil
+
+	case "tag":
+		want := strings.ToLower(strings.TrimPrefix(value, "#"))
+		return func(t Task) bool {
+			for _, tag := range t.Tags() {
+				if tag == want {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case "context":
+		return func(t Task) bool { return strings.EqualFold(t.Context, value) }, nil
+	}
+	return nil, fmt.Errorf("unknown field %q (available: %s)", field, strings.Join(queryFields, ", "))
+}
+
+var statusMatchers = map[string]func(Status) bool{
+	"todo":        func(s Status) bool { return s == StatusTodo },
+	"wip":         func(s Status) bool { return s == StatusInProgress },
+	"started":     func(s Status) bool { return s == StatusInProgress },
+	"in-progress": func(s Status) bool { return s == StatusInProgress },
+	"done":        func(s Status) bool { return s == StatusDone },
+	"blocked":     func(s Status) bool { return s == StatusBlocked },
+	"delegated":   func(s Status) bool { return s == StatusDelegated },
+	"cancelled":   func(s Status) bool { return s == StatusCancelled },
+	"canceled":    func(s Status) bool { return s == StatusCancelled },
+	"open":        func(s Status) bool { return !s.IsClosed() },
+	"closed":      func(s Status) bool { return s.IsClosed() },
+}
+
+// splitComparison splits an operator prefix (<, <=, >, >=, =) off a value; "=" when there is none.
+func splitComparison(v string) (op, rest string) {
+	for _, op := range []string{"<=", ">=", "<", ">", "="} {
+		if strings.HasPrefix(v, op) {
+			return op, v[len(op):]
+		}
+	}
+	return "=", v
+}
+
+// compare applies op to the result of a three-way comparison.
+func compare(op string, c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return c == 0
+}
+
+func compareDurations(a, b time.Duration) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// isEmpty reports whether the query has no terms, and so matches everything.
+func (q query) isEmpty() bool {
+	return len(q.terms) == 0
+}
+
+// Match reports whether a task satisfies every term.
+func (q query) Match(t Task) bool {
+	for _, term := range q.terms {
+		if term.match(t) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight marks the characters of s matched by the query's free-text terms. The table
+// can't render colour inside cells, so each matched character gets a combining underline.
+func (q query) highlight(s string) string {
+	matched := map[int]bool{}
+	for _, term := range q.terms {
+		if term.pattern == "" {
+			continue
+		}
+		if idx, ok := fuzzyMatch(s, term.pattern); ok {
+			for _, i := range idx {
+				matched[i] = true
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		b.WriteRune(r)
+		if matched[i] && !unicode.IsSpace(r) {
+			b.WriteRune('\u0332') // combining low line
+		}
+	}
+	return b.String()
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in s in order, ignoring case, and
+// returns their rune positions in s. A contiguous match is used when there is one.
+func fuzzyMatch(s, pattern string) ([]int, bool) {
+	hay := []rune(strings.ToLower(s))
+	needle := []rune(strings.ToLower(pattern))
+	if len(needle) == 0 {
+		return nil, true
+	}
+	if len(hay) != len([]rune(s)) {
+		// Lower-casing changed the length (rare), so positions wouldn't line up
+		hay = []rune(s)
+	}
+
+	if i := indexRunes(hay, needle); i >= 0 {
+		idx := make([]int, len(needle))
+		for j := range needle {
+			idx[j] = i + j
+		}
+		return idx, true
+	}
+
+	idx := make([]int, 0, len(needle))
+	for i, r := range hay {
+		if r == needle[len(idx)] {
+			idx = append(idx, i)
+			if len(idx) == len(needle) {
+				return idx, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func indexRunes(hay, needle []rune) int {
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		match := true
+		for j, r := range needle {
+			if hay[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func queryTestTasks() []Task {
+	carriedFrom := int64(1)
+	return []Task{
+		{ID: 1, Description: "Patch the web servers #ops", Priority: PriorityA, TimeEstimate: "2h", Status: StatusInProgress, Context: "work"},
+		{ID: 2, Description: "Review backup logs #ops #weekly", Priority: PriorityB, TimeEstimate: "30m", Status: StatusTodo, CarriedFromID: &carriedFrom, Context: "work"},
+		{ID: 3, Description: "Book dentist", Priority: PriorityC, TimeEstimate: "10m", Status: StatusDone, Context: "home"},
+		{ID: 4, Description: "Chase vendor quote", Priority: PriorityD, TimeEstimate: "", Status: StatusBlocked, Context: "work"},
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"", []int64{1, 2, 3, 4}},
+		{"p:A", []int64{1}},
+		{"p:a,b", []int64{1, 2}},
+		{"p:<=B", []int64{1, 2}},
+		{"p:>B", []int64{3, 4}},
+		{"status:wip", []int64{1}},
+		{"status:open", []int64{1, 2, 4}},
+		{"status:closed", []int64{3}},
+		{"est:>1h", []int64{1}},
+		{"est:<=30m", []int64{2, 3}},
+		{"est:2h", []int64{1}},
+		{"carried:yes", []int64{2}},
+		{"carried:no", []int64{1, 3, 4}},
+		{"tag:ops", []int64{1, 2}},
+		{"tag:#WEEKLY", []int64{2}},
+		{"context:home", []int64{3}},
+		{"tag:ops AND p:B", []int64{2}},
+		{"tag:ops -p:A", []int64{2}},
+		{"!tag:ops", []int64{3, 4}},
+		{"NOT status:open", []int64{3}},
+		{"bkp", []int64{2}},           // fuzzy: b-a-c-k-u-p
+		{"web srv", []int64{1}},       // every word must match
+		{`"backup logs"`, []int64{2}}, // quoted phrase
+		{"-dentist", []int64{1, 2, 4}},
+		{"-bk", []int64{1, 2, 3, 4}}, // negated words are substrings, not fuzzy
+		{"zzz", nil},
+		{"http://example", nil}, // unknown field prefix is just text
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, task := range filterTasks(queryTestTasks(), q.Match) {
+				got = append(got, task.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []string{"p:E", "p:<A,B", "status:sleeping", "est:>soon", "carried:maybe", "tag:", `"unclosed`, "NOT", "a OR b"}
+	for _, q := range tests {
+		if _, err := parseQuery(q); err == nil {
+			t.Errorf("parseQuery(%q): expected an error", q)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		s, pattern string
+		want       []int
+	}{
+		{"Backup", "back", []int{0, 1, 2, 3}},
+		{"Review backup", "bkp", []int{7, 10, 12}},
+		{"Review backup", "UP", []int{11, 12}}, // contiguous match preferred over R-e-v-i-e-w's u
+		{"Café menu", "éme", []int{3, 5, 6}},
+		{"Backup", "pub", nil},
+	}
+	for _, tt := range tests {
+		got, ok := fuzzyMatch(tt.s, tt.pattern)
+		if ok != (tt.want != nil) {
+			t.Errorf("fuzzyMatch(%q, %q) ok = %v", tt.s, tt.pattern, ok)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.s, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestQueryHighlight(t *testing.T) {
+	q, _ := parseQuery("bkp p:B -logs")
+	got := q.highlight("Review backup logs")
+	want := "Review b\u0332ack\u0332up\u0332 logs"
+	if got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+
+	q, _ = parseQuery("p:A")
+	if got := q.highlight("Patch servers"); got != "Patch servers" {
+		t.Errorf("field terms shouldn't highlight, got %q", got)
+	}
+}
+
+func TestTaskTags(t *testing.T) {
+	task := Task{Description: "Rotate certs #ops, #Security-2026 and #x! not#this #"}
+	if got := strings.Join(task.Tags(), ","); got != "ops,security-2026,x" {
+		t.Errorf("Tags() = %q", got)
+	}
+}
+
+func TestFilterMatchingNothing(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-02", "Patch web servers", PriorityA, "1h", "default")
+
+	m := newModel(s, "2025-06-02", "default", false)
+	for _, r := range "/zzzznomatch" {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != modeTable || len(m.visibleTasks()) != 0 {
+		t.Fatalf("expected an empty filtered table, mode %v", m.mode)
+	}
+
+	// Every action on the selected task has nothing to act on
+	for _, k := range []string{"d", "b", "e", "x", "h", "p"} {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		if m.mode != modeTable {
+			t.Errorf("%s: expected to stay on the table, mode %v", k, m.mode)
+			m.mode = modeTable
+		}
+	}
+	if tasks, _ := s.GetTasksForDate("2025-06-02", "default"); len(tasks) != 1 || tasks[0].Status != StatusTodo {
+		t.Errorf("expected the hidden task untouched, got %+v", tasks)
+	}
+}
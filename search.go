@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// SearchTasks finds tasks on any date whose description or notes match text, best match first
+// (newest first among equal matches). Each word matches as a whole word or a prefix, so "renew"
+// finds "renewal", and double quotes match a phrase. An empty context searches every context. A
+// task carried over several days is only returned once, as its latest copy.
+func (s *Store) SearchTasks(text, context string) ([]Task, error) {
+	match := ftsQuery(text)
+	if match == "" {
+		return nil, nil
+	}
+
+	where := []string{"id NOT IN (SELECT carried_from_id FROM tasks WHERE carried_from_id IS NOT NULL)"}
+	args := []any{match}
+	if context != "" {
+		where = append(where, "context = ?")
+		args = append(args, context)
+	}
+
+	// bm25 scores lower for better matches; a hit in the description counts for more than one
+	// in the notes.
+	rows, err := s.db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		JOIN (SELECT rowid AS match_id, bm25(tasks_fts, 4.0, 1.0) AS score FROM tasks_fts WHERE tasks_fts MATCH ?)
+			ON match_id = id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY score, date DESC, priority, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// ftsQuery turns search text into an FTS5 MATCH expression. Every word and phrase is quoted, so
+// FTS5 operators and punctuation in the text are searched for rather than interpreted. Words
+// become prefix queries; a quoted phrase must match exactly, except that one still being typed
+// (no closing quote) is a prefix too. Returns "" when there is nothing to search for.
+func ftsQuery(text string) string {
+	var terms []string
+	add := func(s string, prefix bool) {
+		s = strings.TrimRight(s, "*")
+		if !strings.ContainsFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			return
+		}
+		term := `"` + s + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+
+	var word strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			if quoted {
+				add(word.String(), false)
+				word.Reset()
+			} else if word.Len() > 0 {
+				add(word.String(), true)
+				word.Reset()
+			}
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if word.Len() > 0 {
+				add(word.String(), true)
+				word.Reset()
+			}
+		default:
+			word.WriteRune(r)
+		}
+	}
+	add(word.String(), true)
+	return strings.Join(terms, " AND ")
+}
+
+// runSearch implements "gtd search <term> [--context x] [--all-contexts]".
+func runSearch(store *Store, args []string) error {
+	context := config.DefaultContext
+	allContexts := false
+	var words []string
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--all-contexts" {
+			allContexts = true
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		if strings.HasPrefix(args[i], "--") {
+			return fmt.Errorf("unknown argument %q\n%s", args[i], commandUsage("search"))
+		}
+		words = append(words, args[i])
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("%s", commandUsage("search"))
+	}
+	if allContexts {
+		context = ""
+	}
+
+	term := strings.Join(words, " ")
+	tasks, err := store.SearchTasks(term, context)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Search %q · %d result(s)\n\n", term, len(tasks))
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "Date\tTask\tPriority\tStatus"
+	if allContexts {
+		header = "Date\tContext\tTask\tPriority\tStatus"
+	}
+	fmt.Fprintln(w, header)
+	for _, t := range tasks {
+		date, _ := time.Parse("2006-01-02", t.Date)
+		row := []string{date.Format(config.dateLayout()), t.Description, string(t.Priority), t.Status.PrintLabel()}
+		if allContexts {
+			row = append(row[:1], append([]string{t.Context}, row[1:]...)...)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
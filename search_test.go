@@ -0,0 +1,185 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSearchTasks(t *testing.T) {
+	s := newTestStore(t)
+
+	s.AddTask("2025-03-10", "Renew TLS cert for mail", PriorityA, "1h", "work")
+	s.AddTask("2025-06-02", "Renew TLS CERT for web", PriorityB, "1h", "work")
+	s.AddTask("2025-06-02", "Cert renewal reminder", PriorityC, "5m", "home")
+	s.AddTask("2025-06-03", "100% disk on db_1", PriorityA, "1h", "work")
+	s.CreateTask(Task{Date: "2025-06-04", Description: "Swap disk", Priority: PriorityB, TimeEstimate: "1h",
+		Context: "work", Notes: "Bay 3 on the backup server; order from the usual supplier"})
+
+	tests := []struct {
+		text, context string
+		want          []string
+	}{
+		{"renew cert", "work", []string{"Renew TLS CERT for web", "Renew TLS cert for mail"}},                // equal rank: newest first, case-insensitive
+		{"cert", "", []string{"Cert renewal reminder", "Renew TLS CERT for web", "Renew TLS cert for mail"}}, // shorter name ranks higher
+		{"cert mail", "work", []string{"Renew TLS cert for mail"}},
+		{"rene", "home", []string{"Cert renewal reminder"}}, // prefix
+		{`"tls cert for mail"`, "work", []string{"Renew TLS cert for mail"}},
+		{`"cert for" mail`, "work", []string{"Renew TLS cert for mail"}},
+		{`"renew cert"`, "work", nil},                                                      // phrase words must be adjacent
+		{`"tls ce`, "work", []string{"Renew TLS CERT for web", "Renew TLS cert for mail"}}, // unclosed phrase is still being typed
+		{"disk", "work", []string{"100% disk on db_1", "Swap disk"}},                       // notes dilute the score
+		{"supplier", "work", []string{"Swap disk"}},                                        // notes
+		{"db_1", "work", []string{"100% disk on db_1"}},
+		{"cert OR disk", "work", nil}, // operators are searched for, not interpreted
+		{"nothing", "work", nil},
+		{"   ", "work", nil},
+		{"%", "work", nil},
+	}
+	for _, tt := range tests {
+		tasks, err := s.SearchTasks(tt.text, tt.context)
+		if err != nil {
+			t.Fatalf("SearchTasks(%q): %v", tt.text, err)
+		}
+		var got []string
+		for _, task := range tasks {
+			got = append(got, task.Description)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("SearchTasks(%q, %q) = %v, want %v", tt.text, tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"renew cert", `"renew"* AND "cert"*`},
+		{"cert*", `"cert"*`},
+		{`"tls cert" mail`, `"tls cert" AND "mail"*`},
+		{`mail"tls cert"`, `"mail"* AND "tls cert"`},
+		{`"tls ce`, `"tls ce"*`},
+		{"NOT -x:y", `"NOT"* AND "-x:y"*`},
+		{`""  * -`, ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ftsQuery(tt.text); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	s := newTestStore(t)
+	id, _ := s.CreateTask(Task{Date: "2025-06-01", Description: "Patch mail server", Priority: PriorityA, TimeEstimate: "1h", Context: "default"})
+
+	found := func(text string) bool {
+		tasks, err := s.SearchTasks(text, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(tasks) == 1
+	}
+
+	if err := s.UpdateTask(id, "Patch web server", PriorityA, "1h", "", "Needs a reboot window"); err != nil {
+		t.Fatal(err)
+	}
+	if found("mail") || !found("web") || !found("reboot") {
+		t.Error("expected the index to follow the edit")
+	}
+
+	s.DeleteTask(id)
+	if found("web") {
+		t.Error("expected a deleted task to leave the index")
+	}
+}
+
+func TestSearchIndexBuiltForExistingDatabase(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Rotate backup keys", PriorityA, "1h", "default")
+
+	// A database from before the index existed has the tasks but no tasks_fts
+	_, err := s.db.Exec(`
+		DROP TRIGGER tasks_fts_insert;
+		DROP TRIGGER tasks_fts_delete;
+		DROP TRIGGER tasks_fts_update;
+		DROP TABLE tasks_fts;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := s.SearchTasks("backup", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 {
+		t.Errorf("expected existing tasks to be indexed, got %d result(s)", len(tasks))
+	}
+}
+
+func TestSearchTasksLatestCarriedCopy(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-06-01", "Rotate backup keys", PriorityA, "1h", "default")
+	tasks, _ := s.GetTasksForDate("2025-06-01", "default")
+	s.CarryOverTasks(tasks, "2025-06-02", "default")
+
+	results, err := s.SearchTasks("backup", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Date != "2025-06-02" {
+		t.Errorf("expected only the carried copy on 2025-06-02, got %+v", results)
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-03-10", "Renew TLS cert", PriorityA, "1h", "default")
+	s.AddTask("2025-03-11", "Renew TLS cert at home", PriorityA, "1h", "home")
+
+	output := captureOutput(t, func() error { return runSearch(s, []string{"tls", "cert"}) })
+	if !strings.Contains(output, `Search "tls cert" · 1 result(s)`) || !strings.Contains(output, "10/03/2025") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+
+	output = captureOutput(t, func() error { return runSearch(s, []string{"cert", "--all-contexts"}) })
+	if !strings.Contains(output, "2 result(s)") || !strings.Contains(output, "Context") || !strings.Contains(output, "home") {
+		t.Errorf("expected results from every context, got:\n%s", output)
+	}
+
+	for _, args := range [][]string{nil, {"--context"}, {"--bogus", "x"}} {
+		if err := runSearch(s, args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestSearchModeJumpsToTask(t *testing.T) {
+	s := newTestStore(t)
+	s.AddTask("2025-03-10", "Order toner", PriorityB, "5m", "default")
+	s.AddTask("2025-03-10", "Renew TLS cert", PriorityC, "1h", "default")
+	s.AddTask("2025-06-01", "Today's work", PriorityA, "1h", "default")
+
+	m := newModel(s, "2025-06-01", "default", false)
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	if m.mode != modeSearch {
+		t.Fatalf("expected ctrl+f to open search, mode = %v", m.mode)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("cert")})
+	if len(m.searchResults) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(m.searchResults))
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.mode != modeTable || m.date != "2025-03-10" {
+		t.Fatalf("expected to jump to 2025-03-10, got mode %v date %s", m.mode, m.date)
+	}
+	if got := m.visibleTasks()[m.table.Cursor()].Description; got != "Renew TLS cert" {
+		t.Errorf("expected the found task to be selected, got %q", got)
+	}
+}
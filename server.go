@@ -0,0 +1,591 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gtd serve exposes the Store as a small REST JSON API for scripts, bots and dashboards. Dates
+// are yyyy-mm-dd whatever date_format is set to, statuses use the names in apiStatusNames, and
+// errors come back as {"error": "..."} with a 4xx or 5xx code.
+
+// apiStatusNames are the status names the API reads and writes.
+var apiStatusNames = map[Status]string{
+	StatusTodo:       "todo",
+	StatusInProgress: "wip",
+	StatusDone:       "done",
+	StatusBlocked:    "blocked",
+	StatusDelegated:  "delegated",
+	StatusCancelled:  "cancelled",
+}
+
+func parseAPIStatus(name string) (Status, error) {
+	for s, n := range apiStatusNames {
+		if strings.EqualFold(name, n) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("status must be todo, wip, done, blocked, delegated or cancelled, got %q", name)
+}
+
+// apiTask is a task as the API returns it.
+type apiTask struct {
+	ID            int64  `json:"id"`
+	UID           string `json:"uid"`
+	Date          string `json:"date"`
+	Context       string `json:"context"`
+	Description   string `json:"description"`
+	Priority      string `json:"priority"`
+	TimeEstimate  string `json:"time_estimate"`
+	Status        string `json:"status"`
+	DueDate       string `json:"due_date,omitempty"`
+	Notes         string `json:"notes,omitempty"`
+	Pomodoros     int    `json:"pomodoros"`
+	CarriedFromID *int64 `json:"carried_from_id,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+	CompletedAt   string `json:"completed_at,omitempty"`
+}
+
+func newAPITask(t Task) apiTask {
+	a := apiTask{
+		ID: t.ID, UID: t.UID, Date: t.Date, Context: t.Context, Description: t.Description, Priority: string(t.Priority),
+		TimeEstimate: t.TimeEstimate, Status: apiStatusNames[t.Status], DueDate: t.DueDate, Notes: t.Notes,
+		Pomodoros: t.Pomodoros, CarriedFromID: t.CarriedFromID,
+	}
+	for _, ts := range []struct {
+		from time.Time
+		to   *string
+	}{{t.CreatedAt, &a.CreatedAt}, {t.UpdatedAt, &a.UpdatedAt}, {t.CompletedAt, &a.CompletedAt}} {
+		if !ts.from.IsZero() {
+			*ts.to = formatTimestamp(ts.from)
+		}
+	}
+	return a
+}
+
+// apiTaskInput is the body of POST /tasks and PATCH /tasks/{id}. Fields left out keep their
+// defaults (POST) or current values (PATCH).
+type apiTaskInput struct {
+	Date         *string `json:"date"`
+	Context      *string `json:"context"`
+	Description  *string `json:"description"`
+	Priority     *string `json:"priority"`
+	TimeEstimate *string `json:"time_estimate"`
+	DueDate      *string `json:"due_date"`
+	Notes        *string `json:"notes"`
+	Status       *string `json:"status"`
+}
+
+// apply copies the given fields onto t, checking each one.
+func (in apiTaskInput) apply(t *Task) error {
+	if in.Date != nil {
+		if !isISODate(*in.Date) {
+			return fmt.Errorf("date must be yyyy-mm-dd, got %q", *in.Date)
+		}
+		t.Date = *in.Date
+	}
+	if in.Context != nil {
+		if strings.TrimSpace(*in.Context) == "" {
+			return fmt.Errorf("context can't be empty")
+		}
+		t.Context = strings.TrimSpace(*in.Context)
+	}
+	if in.Description != nil {
+		if strings.TrimSpace(*in.Description) == "" {
+			return fmt.Errorf("description can't be empty")
+		}
+		t.Description = strings.TrimSpace(*in.Description)
+	}
+	if in.Priority != nil {
+		p := Priority(strings.ToUpper(*in.Priority))
+		switch p {
+		case PriorityA, PriorityB, PriorityC, PriorityD:
+		default:
+			return fmt.Errorf("priority must be A, B, C or D, got %q", *in.Priority)
+		}
+		t.Priority = p
+	}
+	if in.TimeEstimate != nil {
+		t.TimeEstimate = strings.TrimSpace(*in.TimeEstimate)
+	}
+	if in.DueDate != nil {
+		if *in.DueDate != "" && !isISODate(*in.DueDate) {
+			return fmt.Errorf("due_date must be yyyy-mm-dd or empty, got %q", *in.DueDate)
+		}
+		t.DueDate = *in.DueDate
+	}
+	if in.Notes != nil {
+		t.Notes = *in.Notes
+	}
+	if in.Status != nil {
+		s, err := parseAPIStatus(*in.Status)
+		if err != nil {
+			return err
+		}
+		t.Status = s
+	}
+	return nil
+}
+
+func isISODate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+type apiServer struct {
+	store *Store
+	token string // bearer token required on every request, or "" for none
+}
+
+// newAPIHandler routes the API and the web UI. With a token, every API request needs
+// "Authorization: Bearer <token>".
+func newAPIHandler(store *Store, token string) http.Handler {
+	a := &apiServer{store: store, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks", a.listTasks)
+	mux.HandleFunc("POST /tasks", a.createTask)
+	mux.HandleFunc("GET /tasks/{id}", a.getTask)
+	mux.HandleFunc("PATCH /tasks/{id}", a.updateTask)
+	mux.HandleFunc("DELETE /tasks/{id}", a.deleteTask)
+	mux.HandleFunc("GET /tasks/{id}/status", a.getStatus)
+	mux.HandleFunc("PATCH /tasks/{id}/status", a.setStatus)
+	mux.HandleFunc("POST /carry", a.carry)
+	mux.HandleFunc("GET /contexts", a.listContexts)
+	mux.HandleFunc("PATCH /contexts/{name}", a.updateContext)
+	mux.HandleFunc("DELETE /contexts/{name}", a.deleteContext)
+
+	// The web UI holds no data itself, so it's open; its API calls carry the token
+	root := http.NewServeMux()
+	root.Handle("/", sameSite(a.authenticate(mux)))
+	root.HandleFunc("GET /{$}", a.serveWebUI)
+	root.HandleFunc("GET /theme.css", serveThemeCSS)
+	return root
+}
+
+func (a *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(a.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="gtd"`)
+				writeAPIError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameSite refuses API requests that a web page on another site could make from the user's
+// browser: writes carry an Origin header that must match the Host, and on a loopback address
+// the Host must be localhost or an IP, so a DNS-rebinding page can't reach the API under its
+// own domain name.
+func sameSite(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				writeAPIError(w, http.StatusForbidden, fmt.Errorf("requests from %s aren't allowed", origin))
+				return
+			}
+		}
+		if local, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr); ok && local.IP.IsLoopback() {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if host != "localhost" && net.ParseIP(strings.Trim(host, "[]")) == nil {
+				writeAPIError(w, http.StatusForbidden, fmt.Errorf("unexpected Host %q", r.Host))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// storeError reports an error from the store: 404 when the row doesn't exist, otherwise 500.
+func storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(w, http.StatusNotFound, errors.New("no such task"))
+		return
+	}
+	writeAPIError(w, http.StatusInternalServerError, err)
+}
+
+// readJSON decodes a request body, rejecting unknown fields so typos don't pass silently. The
+// body must be sent as application/json, which a plain HTML form or simple fetch can't do.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		writeAPIError(w, http.StatusUnsupportedMediaType, errors.New("the body must be sent as Content-Type: application/json"))
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("bad JSON body: %w", err))
+		return false
+	}
+	return true
+}
+
+// taskFromPath loads the task named by the {id} path segment.
+func (a *apiServer) taskFromPath(w http.ResponseWriter, r *http.Request) (Task, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("bad task id %q", r.PathValue("id")))
+		return Task{}, false
+	}
+	t, err := a.store.GetTask(id)
+	if err != nil {
+		storeError(w, err)
+		return Task{}, false
+	}
+	return t, true
+}
+
+// listTasks serves GET /tasks?date=yyyy-mm-dd&context=name. The date defaults to today and the
+// context to the default one; context=* lists every context.
+func (a *apiServer) listTasks(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = a.store.now().Format("2006-01-02")
+	} else if !isISODate(date) {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("date must be yyyy-mm-dd, got %q", date))
+		return
+	}
+	context := r.URL.Query().Get("context")
+	if context == "" {
+		context = config.DefaultContext
+	}
+
+	var tasks []Task
+	var err error
+	if context == "*" {
+		tasks, err = a.store.GetTasksForDateAllContexts(date)
+	} else {
+		tasks, err = a.store.GetTasksForDate(date, context)
+	}
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	out := []apiTask{}
+	for _, t := range tasks {
+		out = append(out, newAPITask(t))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// createTask serves POST /tasks. Only the description is required.
+func (a *apiServer) createTask(w http.ResponseWriter, r *http.Request) {
+	var in apiTaskInput
+	if !readJSON(w, r, &in) {
+		return
+	}
+	t := Task{
+		Date:     a.store.now().Format("2006-01-02"),
+		Context:  config.DefaultContext,
+		Priority: config.DefaultPriority,
+	}
+	if err := in.apply(&t); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if t.Description == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("description is required"))
+		return
+	}
+
+	id, err := a.store.CreateTask(t)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	created, err := a.store.GetTask(id)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/tasks/%d", id))
+	writeJSON(w, http.StatusCreated, newAPITask(created))
+}
+
+func (a *apiServer) getTask(w http.ResponseWriter, r *http.Request) {
+	if t, ok := a.taskFromPath(w, r); ok {
+		writeJSON(w, http.StatusOK, newAPITask(t))
+	}
+}
+
+// updateTask serves PATCH /tasks/{id}. Every field is checked before anything is saved; the
+// date can't be changed here, as moving a task to another day is what /carry is for.
+func (a *apiServer) updateTask(w http.ResponseWriter, r *http.Request) {
+	old, ok := a.taskFromPath(w, r)
+	if !ok {
+		return
+	}
+	var in apiTaskInput
+	if !readJSON(w, r, &in) {
+		return
+	}
+	if in.Date != nil && *in.Date != old.Date {
+		writeAPIError(w, http.StatusBadRequest, errors.New("date can't be changed; carry the task instead"))
+		return
+	}
+	t := old
+	if err := in.apply(&t); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.store.UpdateTask(t.ID, t.Description, t.Priority, t.TimeEstimate, t.DueDate, t.Notes)
+	if err == nil && t.Context != old.Context {
+		err = a.store.MoveTask(t.ID, t.Context)
+	}
+	if err == nil && t.Status != old.Status {
+		err = a.store.SetStatus(t.ID, t.Status)
+	}
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	a.getTask(w, r)
+}
+
+func (a *apiServer) deleteTask(w http.ResponseWriter, r *http.Request) {
+	t, ok := a.taskFromPath(w, r)
+	if !ok {
+		return
+	}
+	if err := a.store.DeleteTask(t.ID); err != nil {
+		storeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) getStatus(w http.ResponseWriter, r *http.Request) {
+	if t, ok := a.taskFromPath(w, r); ok {
+		writeJSON(w, http.StatusOK, map[string]string{"status": apiStatusNames[t.Status]})
+	}
+}
+
+// setStatus serves PATCH /tasks/{id}/status with a body of {"status": "done"}.
+func (a *apiServer) setStatus(w http.ResponseWriter, r *http.Request) {
+	t, ok := a.taskFromPath(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		Status string `json:"status"`
+	}
+	if !readJSON(w, r, &in) {
+		return
+	}
+	status, err := parseAPIStatus(in.Status)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := a.store.SetStatus(t.ID, status); err != nil {
+		storeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": apiStatusNames[status]})
+}
+
+// carry serves POST /carry with an optional body of {"context", "from", "to"}. It carries open
+// tasks from one day to another, like pressing c: to defaults to today, and from to the most
+// recent earlier day with open tasks. Tasks already carried are skipped.
+func (a *apiServer) carry(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Context string `json:"context"`
+		From    string `json:"from"`
+		To      string `json:"to"`
+	}
+	if r.ContentLength != 0 && !readJSON(w, r, &in) {
+		return
+	}
+	if in.Context == "" {
+		in.Context = config.DefaultContext
+	}
+	if in.To == "" {
+		in.To = a.store.now().Format("2006-01-02")
+	}
+	for _, d := range []string{in.From, in.To} {
+		if d != "" && !isISODate(d) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("dates must be yyyy-mm-dd, got %q", d))
+			return
+		}
+	}
+	if in.From == "" {
+		from, err := a.store.GetLatestDateWithIncompleteTasks(in.To, in.Context)
+		if err != nil {
+			storeError(w, err)
+			return
+		}
+		in.From = from
+	}
+	if in.From >= in.To && in.From != "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("from must be before to"))
+		return
+	}
+
+	carried := []apiTask{}
+	if in.From != "" {
+		tasks, err := a.store.GetCarryOverCandidates(in.From, in.To, in.Context)
+		if err == nil {
+			err = a.store.CarryOverTasks(tasks, in.To, in.Context)
+		}
+		if err != nil {
+			storeError(w, err)
+			return
+		}
+		for _, t := range tasks {
+			carried = append(carried, newAPITask(t))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"from": in.From, "to": in.To, "context": in.Context, "carried": carried})
+}
+
+// apiContext is a context as GET /contexts returns it.
+type apiContext struct {
+	Name     string `json:"name"`
+	Total    int    `json:"total"`
+	Open     int    `json:"open"`
+	Done     int    `json:"done"`
+	LastDate string `json:"last_date"`
+	Archived bool   `json:"archived"`
+}
+
+func (a *apiServer) listContexts(w http.ResponseWriter, r *http.Request) {
+	contexts, err := a.store.ListContexts()
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	out := []apiContext{}
+	for _, c := range contexts {
+		out = append(out, apiContext{c.Name, c.Total, c.Open, c.Done, c.LastDate, c.Archived})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// updateContext serves PATCH /contexts/{name} with {"archived": bool} to archive or unarchive,
+// {"name": "new"} to rename, or {"merge_into": "other"} to merge.
+func (a *apiServer) updateContext(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var in struct {
+		Archived  *bool  `json:"archived"`
+		Name      string `json:"name"`
+		MergeInto string `json:"merge_into"`
+	}
+	if !readJSON(w, r, &in) {
+		return
+	}
+	if exists, err := a.store.contextExists(name); err != nil {
+		storeError(w, err)
+		return
+	} else if !exists {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no such context %q", name))
+		return
+	}
+
+	var err error
+	switch {
+	case in.Name != "" && in.MergeInto != "":
+		err = errors.New("give either name or merge_into, not both")
+	case in.Name != "":
+		err = a.store.RenameContext(name, in.Name)
+		name = in.Name
+	case in.MergeInto != "":
+		err = a.store.MergeContext(name, in.MergeInto)
+		name = in.MergeInto
+	case in.Archived == nil:
+		err = errors.New("nothing to change: give archived, name or merge_into")
+	}
+	if err == nil && in.Archived != nil {
+		err = a.store.SetContextArchived(name, *in.Archived)
+	}
+	if err != nil {
+		// The store's errors here are all about the request: a name clash or a missing target
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	a.listContexts(w, r)
+}
+
+func (a *apiServer) deleteContext(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	n, err := a.store.DeleteContext(name)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	if n == 0 {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no such context %q", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": n})
+}
+
+// runServe implements "gtd serve [--addr host:port] [--token t]". The token can also come from
+// $GTD_API_TOKEN.
+func runServe(store *Store, args []string) error {
+	usage := commandUsage("serve")
+
+	addr, token := "127.0.0.1:8080", os.Getenv("GTD_API_TOKEN")
+	for i := 0; i < len(args); i++ {
+		if value, next, ok, err := takeFlag(args, i, "--addr"); ok {
+			if err != nil {
+				return err
+			}
+			addr, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--token"); ok {
+			if err != nil {
+				return err
+			}
+			token, i = value, next
+			continue
+		}
+		return fmt.Errorf("unknown argument %q\n%s", args[i], usage)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("--addr: %w", err)
+	}
+	if ip := net.ParseIP(host); token == "" && host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		fmt.Fprintf(os.Stderr, "Warning: serving on %s without --token; anyone who can reach it can change your tasks\n", addr)
+	}
+
+	// SQLite allows one writer at a time; a single connection queues requests rather than
+	// failing them with "database is locked".
+	store.db.SetMaxOpenConns(1)
+
+	server := &http.Server{Addr: addr, Handler: newAPIHandler(store, token), ReadHeaderTimeout: 10 * time.Second}
+	fmt.Printf("Serving gtd for %s on http://%s (web UI at /, API alongside)\n", store.path, addr)
+	return server.ListenAndServe()
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestAPI serves the API over an in-memory store whose clock reads 2 June 2025.
+func newTestAPI(t *testing.T, token string) (*Store, *httptest.Server) {
+	t.Helper()
+	s := newTestStore(t)
+	s.now = func() time.Time { return time.Date(2025, 6, 2, 9, 0, 0, 0, time.Local) }
+	s.db.SetMaxOpenConns(1) // as runServe does; a second connection would see a different in-memory database
+	srv := httptest.NewServer(newAPIHandler(s, token))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// call makes a request and decodes a JSON response into out (when non-nil), returning the status.
+func call(t *testing.T, srv *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("%s %s: bad JSON %q: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPITasks(t *testing.T) {
+	s, srv := newTestAPI(t, "")
+	s.AddTask("2025-06-02", "Order toner", PriorityC, "5m", "default")
+	s.AddTask("2025-06-02", "Fix the fence", PriorityB, "1d", "home")
+
+	var tasks []apiTask
+	if code := call(t, srv, "GET", "/tasks", "", &tasks); code != 200 || len(tasks) != 1 || tasks[0].Description != "Order toner" {
+		t.Fatalf("GET /tasks = %d %+v", code, tasks)
+	}
+	if call(t, srv, "GET", "/tasks?date=2025-06-02&context=*", "", &tasks); len(tasks) != 2 {
+		t.Errorf("expected both contexts, got %+v", tasks)
+	}
+	if call(t, srv, "GET", "/tasks?date=2025-06-01&context=home", "", &tasks); len(tasks) != 0 {
+		t.Errorf("expected no tasks on 1 June, got %+v", tasks)
+	}
+
+	var created apiTask
+	code := call(t, srv, "POST", "/tasks", `{"description": "Patch web servers", "priority": "a", "time_estimate": "2h", "due_date": "2025-06-05", "notes": "web01 first"}`, &created)
+	if code != 201 || created.ID == 0 || created.Priority != "A" || created.Date != "2025-06-02" || created.Context != "default" ||
+		created.Status != "todo" || created.DueDate != "2025-06-05" || created.UID == "" {
+		t.Fatalf("POST /tasks = %d %+v", code, created)
+	}
+
+	var patched apiTask
+	code = call(t, srv, "PATCH", "/tasks/"+itoa(created.ID), `{"description": "Patch all web servers", "status": "wip", "context": "work"}`, &patched)
+	if code != 200 || patched.Description != "Patch all web servers" || patched.Status != "wip" || patched.Context != "work" || patched.TimeEstimate != "2h" {
+		t.Errorf("PATCH /tasks/{id} = %d %+v", code, patched)
+	}
+	events, _ := s.GetTaskEvents(created.ID)
+	if len(events) != 4 {
+		t.Errorf("expected created, edited, moved and started events, got %+v", events)
+	}
+
+	var got apiTask
+	if code := call(t, srv, "GET", "/tasks/"+itoa(created.ID), "", &got); code != 200 || got.Notes != "web01 first" {
+		t.Errorf("GET /tasks/{id} = %d %+v", code, got)
+	}
+	if code := call(t, srv, "DELETE", "/tasks/"+itoa(created.ID), "", nil); code != 204 {
+		t.Errorf("DELETE /tasks/{id} = %d", code)
+	}
+	var apiErr map[string]string
+	if code := call(t, srv, "GET", "/tasks/"+itoa(created.ID), "", &apiErr); code != 404 || apiErr["error"] == "" {
+		t.Errorf("GET of a deleted task = %d %v", code, apiErr)
+	}
+}
+
+func TestAPIValidation(t *testing.T) {
+	s, srv := newTestAPI(t, "")
+	id, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Order toner", Priority: PriorityB, Context: "default"})
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/tasks?date=02/06/2025", "", 400},
+		{"POST", "/tasks", `{}`, 400},
+		{"POST", "/tasks", `{"description": "x", "priority": "E"}`, 400},
+		{"POST", "/tasks", `{"description": "x", "date": "tomorrow"}`, 400},
+		{"POST", "/tasks", `{"description": "x", "colour": "red"}`, 400},
+		{"POST", "/tasks", `not json`, 400},
+		{"PATCH", "/tasks/" + itoa(id), `{"date": "2025-06-03"}`, 400},
+		{"PATCH", "/tasks/" + itoa(id), `{"status": "someday"}`, 400},
+		{"PATCH", "/tasks/" + itoa(id), `{"due_date": "soon"}`, 400},
+		{"PATCH", "/tasks/999", `{"notes": "x"}`, 404},
+		{"PATCH", "/tasks/abc", `{}`, 400},
+		{"DELETE", "/tasks/999", "", 404},
+		{"PUT", "/tasks/" + itoa(id), `{}`, 405},
+		{"GET", "/nowhere", "", 404},
+	}
+	for _, tt := range tests {
+		if code := call(t, srv, tt.method, tt.path, tt.body, nil); code != tt.want {
+			t.Errorf("%s %s %s = %d, want %d", tt.method, tt.path, tt.body, code, tt.want)
+		}
+	}
+
+	// Nothing was saved by a rejected PATCH
+	task, _ := s.GetTask(id)
+	if task.Description != "Order toner" || task.Status != StatusTodo || task.DueDate != "" {
+		t.Errorf("a rejected request changed the task: %+v", task)
+	}
+}
+
+func TestAPIStatus(t *testing.T) {
+	s, srv := newTestAPI(t, "")
+	id, _ := s.CreateTask(Task{Date: "2025-06-02", Description: "Order toner", Priority: PriorityB, Context: "default"})
+	path := "/tasks/" + itoa(id) + "/status"
+
+	var status map[string]string
+	if code := call(t, srv, "GET", path, "", &status); code != 200 || status["status"] != "todo" {
+		t.Errorf("GET status = %d %v", code, status)
+	}
+	if code := call(t, srv, "PATCH", path, `{"status": "DONE"}`, &status); code != 200 || status["status"] != "done" {
+		t.Errorf("PATCH status = %d %v", code, status)
+	}
+	if task, _ := s.GetTask(id); task.Status != StatusDone || task.CompletedAt.IsZero() {
+		t.Errorf("expected the task done with a completion time, got %+v", task)
+	}
+	if code := call(t, srv, "PATCH", path, `{"status": "finished"}`, nil); code != 400 {
+		t.Errorf("PATCH with a bad status = %d, want 400", code)
+	}
+}
+
+func TestAPICarry(t *testing.T) {
+	s, srv := newTestAPI(t, "")
+	s.AddTask("2025-05-30", "Order toner", PriorityC, "5m", "default")
+	s.AddTask("2025-05-30", "Already done", PriorityB, "", "default")
+	done, _ := s.GetTasksForDate("2025-05-30", "default")
+	s.MarkComplete(done[1].ID)
+
+	var result struct {
+		From    string    `json:"from"`
+		To      string    `json:"to"`
+		Carried []apiTask `json:"carried"`
+	}
+	if code := call(t, srv, "POST", "/carry", "", &result); code != 200 || result.From != "2025-05-30" || result.To != "2025-06-02" || len(result.Carried) != 1 {
+		t.Fatalf("POST /carry = %d %+v", code, result)
+	}
+	if today, _ := s.GetTasksForDate("2025-06-02", "default"); len(today) != 1 || !today[0].WasCarriedOver() {
+		t.Errorf("expected the open task carried to today, got %+v", today)
+	}
+
+	// Carrying again finds nothing new
+	if call(t, srv, "POST", "/carry", `{"from": "2025-05-30"}`, &result); len(result.Carried) != 0 {
+		t.Errorf("expected nothing left to carry, got %+v", result.Carried)
+	}
+	if code := call(t, srv, "POST", "/carry", `{"from": "2025-06-03", "to": "2025-06-02"}`, nil); code != 400 {
+		t.Errorf("carrying backwards = %d, want 400", code)
+	}
+}
+
+func TestAPIContexts(t *testing.T) {
+	s, srv := newTestAPI(t, "")
+	s.AddTask("2025-06-02", "Order toner", PriorityC, "5m", "work")
+	s.AddTask("2025-06-02", "Fix the fence", PriorityB, "1d", "home")
+	s.AddTask("2025-06-02", "Mow the lawn", PriorityB, "1h", "garden")
+
+	var contexts []apiContext
+	if code := call(t, srv, "GET", "/contexts", "", &contexts); code != 200 || len(contexts) != 3 {
+		t.Fatalf("GET /contexts = %d %+v", code, contexts)
+	}
+	if call(t, srv, "PATCH", "/contexts/work", `{"archived": true}`, &contexts); !contexts[2].Archived {
+		t.Errorf("expected work archived, got %+v", contexts)
+	}
+	if call(t, srv, "PATCH", "/contexts/garden", `{"merge_into": "home"}`, &contexts); len(contexts) != 2 || contexts[0].Total != 2 {
+		t.Errorf("expected garden merged into home, got %+v", contexts)
+	}
+	if call(t, srv, "PATCH", "/contexts/home", `{"name": "house"}`, &contexts); contexts[0].Name != "house" {
+		t.Errorf("expected home renamed, got %+v", contexts)
+	}
+
+	var deleted map[string]int
+	if code := call(t, srv, "DELETE", "/contexts/house", "", &deleted); code != 200 || deleted["deleted"] != 2 {
+		t.Errorf("DELETE /contexts/house = %d %v", code, deleted)
+	}
+	for _, tt := range []struct{ method, path, body string }{
+		{"DELETE", "/contexts/nowhere", ""},
+		{"PATCH", "/contexts/nowhere", `{"archived": true}`},
+		{"PATCH", "/contexts/work", `{}`},
+		{"PATCH", "/contexts/work", `{"name": "a", "merge_into": "b"}`},
+		{"PATCH", "/contexts/work", `{"merge_into": "missing"}`},
+	} {
+		if code := call(t, srv, tt.method, tt.path, tt.body, nil); code < 400 {
+			t.Errorf("%s %s %s = %d, want an error", tt.method, tt.path, tt.body, code)
+		}
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	_, srv := newTestAPI(t, "s3cret")
+
+	for _, header := range []string{"", "Bearer wrong", "s3cret", "Basic s3cret"} {
+		req, _ := http.NewRequest("GET", srv.URL+"/tasks", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 401 || resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: got %d, want 401 with a challenge", header, resp.StatusCode)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"/tasks", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("with the right token: got %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestAPIRefusesOtherSites(t *testing.T) {
+	s, srv := newTestAPI(t, "")
+	s.AddTask("2025-06-01", "Order toner", PriorityC, "5m", "default")
+
+	tests := []struct {
+		name, method, path, contentType, origin, host string
+		want                                          int
+	}{
+		{"form post", "POST", "/tasks", "text/plain", "", "", 415},
+		{"no content type", "POST", "/tasks", "", "", "", 415},
+		{"other origin", "POST", "/carry", "", "http://evil.example", "", 403},
+		{"rebound host", "GET", "/tasks", "", "", "evil.example:8080", 403},
+		{"same origin", "POST", "/tasks", "application/json; charset=utf-8", srv.URL, "", 201},
+		{"localhost", "GET", "/tasks", "", "", "localhost:8080", 200},
+	}
+	for _, tt := range tests {
+		body := ""
+		if tt.path == "/tasks" && tt.method == "POST" {
+			body = `{"description": "Sneaky"}`
+		}
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	if tasks, _ := s.GetTasksForDate("2025-06-02", "default"); len(tasks) != 1 {
+		t.Errorf("expected only the same-origin task added and nothing carried, got %+v", tasks)
+	}
+}
+
+func TestRunServeErrors(t *testing.T) {
+	s := newTestStore(t)
+	for _, args := range [][]string{{"--bogus"}, {"--addr"}, {"--addr", "no-port"}} {
+		if err := runServe(s, args); err == nil {
+			t.Errorf("runServe(%v): expected an error", args)
+		}
+	}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Snapshots are whole-database copies taken with VACUUM INTO, which writes a consistent,
+// compacted copy even while the database is in use. They go in a backups folder beside the
+// database and are taken before anything that removes or rewrites tasks in bulk, before a
+// schema migration, and on the first start of each day.
+
+// snapshotStamp is the UTC time in a snapshot's file name; it sorts, and has no colons for Windows.
+const snapshotStamp = "20060102T150405Z"
+
+var snapshotName = regexp.MustCompile(`^tasks-(\d{8}T\d{6}Z)-([a-z]+)(?:-\d+)?\.db$`)
+
+// snapshot is one file in the backups folder.
+type snapshot struct {
+	Path   string
+	Taken  time.Time
+	Reason string // what it was taken before: delete, carry, import, restore, migrate or daily
+	Size   int64
+}
+
+// snapshotDir is the backups folder beside the database.
+func (s *Store) snapshotDir() string {
+	return filepath.Join(filepath.Dir(s.path), "backups")
+}
+
+// snapshotsEnabled is false for in-memory databases and when snapshot_keep_copies is 0.
+func (s *Store) snapshotsEnabled() bool {
+	return config.SnapshotKeepCopies > 0 && s.path != ":memory:" && !strings.HasPrefix(s.path, "file:")
+}
+
+// Snapshot copies the database into the backups folder and prunes snapshots past the retention
+// limits. It returns the new file, or "" when snapshots are off.
+func (s *Store) Snapshot(reason string) (string, error) {
+	if !s.snapshotsEnabled() {
+		return "", nil
+	}
+	dir := s.snapshotDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	// VACUUM INTO won't overwrite, so a second snapshot in the same second gets a suffix
+	base := "tasks-" + s.now().UTC().Format(snapshotStamp) + "-" + reason
+	path := filepath.Join(dir, base+".db")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.db", base, n))
+	}
+
+	if _, err := s.db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return "", err
+	}
+	return path, s.pruneSnapshots()
+}
+
+// snapshotBefore takes a snapshot ahead of a destructive operation, so the operation can be
+// undone with gtd backup restore.
+func (s *Store) snapshotBefore(op string) error {
+	if _, err := s.Snapshot(op); err != nil {
+		return fmt.Errorf("snapshot before %s: %w", op, err)
+	}
+	return nil
+}
+
+// DailySnapshot takes a snapshot unless one has already been taken today.
+func (s *Store) DailySnapshot() error {
+	if !s.snapshotsEnabled() {
+		return nil
+	}
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	today := s.now().Format("2006-01-02")
+	if len(snapshots) > 0 && snapshots[0].Taken.Local().Format("2006-01-02") == today {
+		return nil
+	}
+	_, err = s.Snapshot("daily")
+	return err
+}
+
+// ListSnapshots returns the snapshots in the backups folder, newest first.
+func (s *Store) ListSnapshots() ([]snapshot, error) {
+	entries, err := os.ReadDir(s.snapshotDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []snapshot
+	for _, e := range entries {
+		m := snapshotName.FindStringSubmatch(e.Name())
+		if m == nil || e.IsDir() {
+			continue
+		}
+		taken, err := time.Parse(snapshotStamp, m[1])
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot{
+			Path: filepath.Join(s.snapshotDir(), e.Name()), Taken: taken, Reason: m[2], Size: info.Size(),
+		})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if !snapshots[i].Taken.Equal(snapshots[j].Taken) {
+			return snapshots[i].Taken.After(snapshots[j].Taken)
+		}
+		return snapshots[i].Path > snapshots[j].Path
+	})
+	return snapshots, nil
+}
+
+// pruneSnapshots keeps the newest snapshot_keep_copies snapshots, dropping any older than
+// snapshot_keep_days (0 means no age limit). The newest snapshot of each reason is kept on top
+// of the count, so a run of deletes can't push out the daily or pre-migration snapshot; the
+// newest snapshot of all is always kept.
+func (s *Store) pruneSnapshots() error {
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	cutoff := s.now().AddDate(0, 0, -config.SnapshotKeepDays)
+	seen := map[string]bool{}
+	for i, snap := range snapshots {
+		newestOfReason := !seen[snap.Reason]
+		seen[snap.Reason] = true
+		tooOld := config.SnapshotKeepDays > 0 && snap.Taken.Before(cutoff)
+		if i == 0 || (!tooOld && (i < config.SnapshotKeepCopies || newestOfReason)) {
+			continue
+		}
+		if err := os.Remove(snap.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findSnapshot resolves a snapshot given as a file name from gtd backup list or as a path.
+func (s *Store) findSnapshot(name string) (string, error) {
+	for _, path := range []string{filepath.Join(s.snapshotDir(), name), name} {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no snapshot %q in %s", name, s.snapshotDir())
+}
+
+// checkSnapshot makes sure a file is a readable gtd database before it replaces the real one.
+func checkSnapshot(path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow(`PRAGMA quick_check`).Scan(&result); err != nil {
+		return fmt.Errorf("%s is not a readable database: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%s is damaged: %s", path, result)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tasks`).Scan(&n); err != nil {
+		return fmt.Errorf("%s is not a gtd database: %w", path, err)
+	}
+	return nil
+}
+
+// RestoreSnapshot replaces the database with a snapshot and reopens it, migrating the snapshot
+// if it came from an older version. The current database is snapshotted first so the restore
+// can itself be undone; saved is that snapshot, or "" if the current database couldn't be
+// copied (it may be the reason for the restore).
+func (s *Store) RestoreSnapshot(path string) (saved string, err error) {
+	if err := checkSnapshot(path); err != nil {
+		return "", err
+	}
+
+	// Copy the snapshot aside first: the snapshot taken below may prune the one being restored
+	staged := s.path + ".restore"
+	if err := copyFile(path, staged); err != nil {
+		return "", err
+	}
+	defer os.Remove(staged)
+
+	saved, _ = s.Snapshot("restore")
+
+	if err := s.db.Close(); err != nil {
+		return saved, err
+	}
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		os.Remove(s.path + suffix)
+	}
+	if err := os.Rename(staged, s.path); err != nil {
+		return saved, err
+	}
+
+	db, err := sql.Open("sqlite", s.path)
+	if err != nil {
+		return saved, fmt.Errorf("open db: %w", err)
+	}
+	s.db = db
+	return saved, s.migrate()
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	return dst.Close()
+}
+
+// runSnapshotList implements "gtd backup list".
+func runSnapshotList(store *Store) error {
+	if !store.snapshotsEnabled() {
+		fmt.Println("Snapshots are off (snapshot_keep_copies = 0).")
+		return nil
+	}
+	snapshots, err := store.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots in %s yet.\n", store.snapshotDir())
+		return nil
+	}
+
+	fmt.Printf("Snapshots in %s, newest first:\n\n", store.snapshotDir())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Snapshot\tTaken\tBefore\tSize")
+	for _, snap := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", filepath.Base(snap.Path), snap.Taken.Local().Format(config.dateLayout()+" 15:04"),
+			snap.Reason, formatSize(snap.Size))
+	}
+	return w.Flush()
+}
+
+// runSnapshotRestore implements "gtd backup restore <snapshot> --yes".
+func runSnapshotRestore(store *Store, args []string) error {
+	usage := commandUsage("backup restore")
+
+	var name string
+	yes := false
+	for _, arg := range args {
+		switch {
+		case arg == "--yes":
+			yes = true
+		case name == "" && !strings.HasPrefix(arg, "-"):
+			name = arg
+		default:
+			return fmt.Errorf("unknown argument %q\n%s", arg, usage)
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("%s", usage)
+	}
+
+	path, err := store.findSnapshot(name)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		return fmt.Errorf("this would replace %s with %s; re-run with --yes to confirm", store.path, filepath.Base(path))
+	}
+
+	saved, err := store.RestoreSnapshot(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from %s.\n", store.path, filepath.Base(path))
+	if saved != "" {
+		fmt.Printf("The database as it was before is in %s.\n", saved)
+	}
+	return nil
+}
+
+// formatSize shows a file size in B, KB or MB.
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.0f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
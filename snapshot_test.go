@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFileStore opens a store on a file in a temporary directory, since snapshots are skipped
+// for in-memory databases. Its clock starts at 1 June 2025 09:00 UTC and advances a minute per
+// reading, so snapshot names don't collide.
+func newFileStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return s
+}
+
+func snapshotReasons(t *testing.T, s *Store) string {
+	t.Helper()
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reasons []string
+	for _, snap := range snapshots {
+		reasons = append(reasons, snap.Reason)
+	}
+	return strings.Join(reasons, ",")
+}
+
+func TestSnapshotsBeforeDestructiveOperations(t *testing.T) {
+	s := newFileStore(t)
+	if got := snapshotReasons(t, s); got != "" {
+		t.Fatalf("a new database shouldn't be snapshotted, got %s", got)
+	}
+
+	s.AddTask("2025-06-01", "Patch web servers", PriorityA, "2h", "work")
+	s.AddTask("2025-06-01", "Order toner", PriorityC, "5m", "work")
+	tasks, _ := s.GetTasksForDate("2025-06-01", "work")
+
+	s.CarryOverTasks(tasks[:1], "2025-06-02", "work")
+	s.DeleteTask(tasks[1].ID)
+	s.ImportTasks([]Task{{Date: "2025-06-03", Description: "Imported", Priority: PriorityB, Context: "work"}}, "test")
+	s.DeleteContext("work")
+
+	if got := snapshotReasons(t, s); got != "delete,import,delete,carry" {
+		t.Errorf("snapshots = %s", got)
+	}
+
+	// The snapshot before the delete still has the deleted task
+	snapshots, _ := s.ListSnapshots()
+	snap, err := NewStoreWithPath(snapshots[2].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Close()
+	if got, _ := snap.GetTasksForDate("2025-06-01", "work"); len(got) != 2 {
+		t.Errorf("expected both tasks in the pre-delete snapshot, got %+v", got)
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.SnapshotKeepCopies = 3
+	cfg.SnapshotKeepDays = 1
+	useConfig(t, cfg)
+
+	s := newFileStore(t)
+	for range 5 {
+		if _, err := s.Snapshot("daily"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := snapshotReasons(t, s); got != "daily,daily,daily" {
+		t.Errorf("expected 3 copies kept, got %s", got)
+	}
+
+	// Two days later only the new snapshot is young enough to keep
+	s.now = func() time.Time { return time.Date(2025, 6, 3, 12, 0, 0, 0, time.UTC) }
+	s.Snapshot("delete")
+	if got := snapshotReasons(t, s); got != "delete" {
+		t.Errorf("expected only the newest snapshot, got %s", got)
+	}
+
+	// Two in the same second both survive
+	s.Snapshot("delete")
+	snapshots, _ := s.ListSnapshots()
+	if len(snapshots) != 2 || snapshots[0].Path == snapshots[1].Path {
+		t.Errorf("expected two distinct snapshots, got %+v", snapshots)
+	}
+
+	cfg.SnapshotKeepCopies = 0
+	useConfig(t, cfg)
+	if path, err := s.Snapshot("delete"); path != "" || err != nil {
+		t.Errorf("snapshot_keep_copies = 0 should turn snapshots off, got %q, %v", path, err)
+	}
+}
+
+func TestSnapshotRetentionKeepsEachReason(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.SnapshotKeepCopies = 3
+	useConfig(t, cfg)
+
+	s := newFileStore(t)
+	s.Snapshot("migrate")
+	s.Snapshot("daily")
+	s.Snapshot("daily")
+	for range 5 {
+		s.Snapshot("delete")
+	}
+	if got := snapshotReasons(t, s); got != "delete,delete,delete,daily,migrate" {
+		t.Errorf("expected the newest daily and migrate snapshots kept past the count, got %s", got)
+	}
+}
+
+func TestDailySnapshot(t *testing.T) {
+	s := newFileStore(t)
+	s.DailySnapshot()
+	s.DailySnapshot()
+	if got := snapshotReasons(t, s); got != "daily" {
+		t.Errorf("expected one daily snapshot, got %s", got)
+	}
+
+	s.now = func() time.Time { return time.Date(2025, 6, 5, 12, 0, 0, 0, time.UTC) }
+	s.DailySnapshot()
+	if got := snapshotReasons(t, s); got != "daily,daily" {
+		t.Errorf("expected a new daily snapshot on a later day, got %s", got)
+	}
+}
+
+func TestSnapshotBeforeMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTask("2025-06-01", "Old task", PriorityB, "", "default")
+	s.db.Exec(`PRAGMA user_version = 0`)
+	s.Close()
+
+	s, err = NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got := snapshotReasons(t, s); got != "migrate" {
+		t.Errorf("expected a snapshot before migrating, got %q", got)
+	}
+	var version int
+	s.db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	if version != schemaVersion {
+		t.Errorf("user_version = %d, want %d", version, schemaVersion)
+	}
+}
+
+func TestBackupListAndRestore(t *testing.T) {
+	s := newFileStore(t)
+	s.AddTask("2025-06-01", "Keep me", PriorityA, "", "work")
+	tasks, _ := s.GetTasksForDate("2025-06-01", "work")
+	s.DeleteTask(tasks[0].ID)
+
+	output := captureOutput(t, func() error { return runBackup(s, []string{"list"}) })
+	if !strings.Contains(output, "tasks-20250601T") || !strings.Contains(output, "-delete.db") || !strings.Contains(output, "delete") {
+		t.Fatalf("unexpected list:\n%s", output)
+	}
+	snapshots, _ := s.ListSnapshots()
+	name := filepath.Base(snapshots[0].Path)
+
+	if err := runBackup(s, []string{"restore", name}); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("restore without --yes should ask for confirmation, got %v", err)
+	}
+	msg := captureOutput(t, func() error { return runBackup(s, []string{"restore", name, "--yes"}) })
+	if !strings.Contains(msg, "Restored") || !strings.Contains(msg, "-restore.db") {
+		t.Errorf("unexpected restore message: %q", msg)
+	}
+
+	// The store is usable straight away, with the deleted task back
+	if got, err := s.GetTasksForDate("2025-06-01", "work"); err != nil || len(got) != 1 || got[0].Description != "Keep me" {
+		t.Errorf("expected the task back, got %+v (%v)", got, err)
+	}
+	if got := snapshotReasons(t, s); got != "restore,delete" {
+		t.Errorf("snapshots = %s", got)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.db")
+	os.WriteFile(bad, []byte("not a database"), 0o644)
+	for _, args := range [][]string{{"restore"}, {"restore", "missing.db", "--yes"}, {"restore", bad, "--yes"}, {"restore", name, "--bogus"}, {"list", "extra"}, {"bogus"}} {
+		if err := runBackup(s, args); err == nil {
+			t.Errorf("runBackup(%v): expected an error", args)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{512: "512 B", 2048: "2 KB", 3 * 1024 * 1024: "3.0 MB"} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// completionCount tallies finished tasks against all tasks that count (cancelled excluded).
+type completionCount struct {
+	Total int
+	Done  int
+}
+
+func (c completionCount) Rate() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.Done) / float64(c.Total)
+}
+
+type dayStats struct {
+	Date string // yyyy-mm-dd
+	completionCount
+}
+
+// Stats summarises completion history for a context from a given date onwards.
+type Stats struct {
+	Context string
+	Since   string // yyyy-mm-dd
+
+	Days       []dayStats
+	Priorities map[Priority]completionCount
+	Weekdays   [7]completionCount // indexed by time.Weekday
+
+	// AverageCarryCount is how many times a task is carried over on average before it stops
+	// moving (it is finished, cancelled, or still on its latest day).
+	AverageCarryCount float64
+
+	// Estimate versus actual, over done tasks that have a parseable, non-zero estimate and a
+	// recorded start. Actual time is from the first "started" event to completion.
+	EstimateSamples int
+	Estimated       time.Duration
+	Actual          time.Duration
+
+	// LongestAStreak is the longest run of days where every A task was done. Days with no
+	// A tasks neither extend nor break a streak.
+	LongestAStreak int
+}
+
+// GetStats computes completion statistics for a context over tasks planned on or after since.
+func (s *Store) GetStats(context, since string) (Stats, error) {
+	st := Stats{Context: context, Since: since, Priorities: map[Priority]completionCount{}}
+
+	rows, err := s.db.Query(`
+		SELECT date,
+		       COUNT(*),
+		       SUM(status = ?),
+		       SUM(priority = 'A'),
+		       SUM(priority = 'A' AND status = ?)
+		FROM tasks
+		WHERE context = ? AND date >= ? AND status != ?
+		GROUP BY date
+		ORDER BY date`, StatusDone, StatusDone, context, since, StatusCancelled)
+	if err != nil {
+		return Stats{}, err
+	}
+	streak := 0
+	for rows.Next() {
+		var d dayStats
+		var aTotal, aDone int
+		if err := rows.Scan(&d.Date, &d.Total, &d.Done, &aTotal, &aDone); err != nil {
+			rows.Close()
+			return Stats{}, err
+		}
+		st.Days = append(st.Days, d)
+
+		if t, err := time.Parse("2006-01-02", d.Date); err == nil {
+			w := st.Weekdays[t.Weekday()]
+			w.Total += d.Total
+			w.Done += d.Done
+			st.Weekdays[t.Weekday()] = w
+		}
+
+		switch {
+		case aTotal == 0:
+		case aDone == aTotal:
+			streak++
+			st.LongestAStreak = max(st.LongestAStreak, streak)
+		default:
+			streak = 0
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Stats{}, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT priority, COUNT(*), SUM(status = ?)
+		FROM tasks
+		WHERE context = ? AND date >= ? AND status != ?
+		GROUP BY priority
+		ORDER BY priority`, StatusDone, context, since, StatusCancelled)
+	if err != nil {
+		return Stats{}, err
+	}
+	for rows.Next() {
+		var p Priority
+		var c completionCount
+		if err := rows.Scan(&p, &c.Total, &c.Done); err != nil {
+			rows.Close()
+			return Stats{}, err
+		}
+		st.Priorities[p] = c
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Stats{}, err
+	}
+
+	if err := s.addLineageStats(&st); err != nil {
+		return Stats{}, err
+	}
+	return st, nil
+}
+
+// addLineageStats fills in the carry and estimate figures, which need each task's carry
+// lineage and so are worked out in Go rather than SQL.
+func (s *Store) addLineageStats(st *Stats) error {
+	type node struct {
+		date         string
+		carriedFrom  int64
+		carriedOn    bool
+		status       Status
+		estimate     string
+		completedAt  time.Time
+		firstStarted time.Time
+	}
+	nodes := map[int64]*node{}
+
+	// Lineage can reach back before the since date, so load the whole context.
+	rows, err := s.db.Query(`
+		SELECT id, date, COALESCE(carried_from_id, 0), status, time_estimate, completed_at
+		FROM tasks WHERE context = ?`, st.Context)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var n node
+		var status int
+		var completedAt string
+		if err := rows.Scan(&id, &n.date, &n.carriedFrom, &status, &n.estimate, &completedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		n.status = Status(status)
+		n.completedAt = parseTimestamp(completedAt)
+		nodes[id] = &n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT e.task_id, MIN(e.created_at)
+		FROM task_events e JOIN tasks t ON t.id = e.task_id
+		WHERE t.context = ? AND e.event = 'started'
+		GROUP BY e.task_id`, st.Context)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var at string
+		if err := rows.Scan(&id, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		if n, ok := nodes[id]; ok {
+			n.firstStarted = parseTimestamp(at)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if parent, ok := nodes[n.carriedFrom]; ok {
+			parent.carriedOn = true
+		}
+	}
+
+	tips, carries := 0, 0
+	for _, n := range nodes {
+		if n.carriedOn || n.date < st.Since {
+			continue
+		}
+		tips++
+
+		// Walk back through the copies this task was carried from.
+		started := n.firstStarted
+		seen := map[int64]bool{}
+		for id := n.carriedFrom; id != 0 && !seen[id]; {
+			seen[id] = true
+			parent, ok := nodes[id]
+			if !ok {
+				break
+			}
+			carries++
+			if !parent.firstStarted.IsZero() && (started.IsZero() || parent.firstStarted.Before(started)) {
+				started = parent.firstStarted
+			}
+			id = parent.carriedFrom
+		}
+
+		if n.status != StatusDone || started.IsZero() || n.completedAt.Before(started) {
+			continue
+		}
+		if est, ok := parseEstimate(n.estimate); ok && est > 0 {
+			st.EstimateSamples++
+			st.Estimated += est
+			st.Actual += n.completedAt.Sub(started)
+		}
+	}
+	if tips > 0 {
+		st.AverageCarryCount = float64(carries) / float64(tips)
+	}
+	return nil
+}
+
+// runStats implements "gtd stats [--context x] [--since dd/mm/yyyy]".
+func runStats(store *Store, args []string) error {
+	context := config.DefaultContext
+	since := time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+
+	for i := 0; i < len(args); i++ {
+		if value, next, ok, err := takeFlag(args, i, "--context"); ok {
+			if err != nil {
+				return err
+			}
+			context, i = value, next
+			continue
+		}
+		if value, next, ok, err := takeFlag(args, i, "--since"); ok {
+			if err != nil {
+				return err
+			}
+			date, err := parseInputDate(value)
+			if err != nil {
+				return err
+			}
+			since, i = date, next
+			continue
+		}
+		return fmt.Errorf("unknown argument %q\n%s", args[i], commandUsage("stats"))
+	}
+
+	st, err := store.GetStats(context, since)
+	if err != nil {
+		return err
+	}
+	fmt.Print(renderStats(st, false))
+	return nil
+}
+
+// renderStats formats a stats report. When styled, bars are coloured for the TUI.
+func renderStats(st Stats, styled bool) string {
+	var s strings.Builder
+
+	sinceDate, _ := time.Parse("2006-01-02", st.Since)
+	heading := fmt.Sprintf("Stats since %s", sinceDate.Format("Monday 2 January 2006"))
+	if st.Context != config.DefaultContext {
+		heading += " · " + st.Context
+	}
+	s.WriteString(heading + "\n\n")
+
+	if len(st.Days) == 0 {
+		s.WriteString("No tasks in this period.\n")
+		return s.String()
+	}
+
+	barColor := theme.Success
+
+	s.WriteString("Completion by day\n")
+	for _, d := range st.Days {
+		t, _ := time.Parse("2006-01-02", d.Date)
+		s.WriteString(statsLine(t.Format("Mon "+config.shortDateLayout()), d.completionCount, barColor, styled))
+	}
+
+	s.WriteString("\nCompletion by priority\n")
+	for _, p := range []Priority{PriorityA, PriorityB, PriorityC, PriorityD} {
+		if c, ok := st.Priorities[p]; ok {
+			s.WriteString(statsLine(string(p), c, p.Color(), styled))
+		}
+	}
+
+	s.WriteString("\nCompletion by weekday\n")
+	for i := 0; i < 7; i++ {
+		day := (config.weekStart() + time.Weekday(i)) % 7
+		if c := st.Weekdays[day]; c.Total > 0 {
+			s.WriteString(statsLine(day.String()[:3], c, barColor, styled))
+		}
+	}
+
+	s.WriteString("\n")
+	s.WriteString(fmt.Sprintf("Average carry count:       %.1f\n", st.AverageCarryCount))
+	if st.EstimateSamples > 0 && st.Estimated > 0 {
+		s.WriteString(fmt.Sprintf("Estimate vs actual:        %s estimated, %s actual over %d task(s) (%.0f%%)\n",
+			formatDuration(st.Estimated), formatDuration(st.Actual), st.EstimateSamples,
+			100*st.Actual.Hours()/st.Estimated.Hours()))
+	} else {
+		s.WriteString("Estimate vs actual:        no started-and-finished tasks with estimates yet\n")
+	}
+	s.WriteString(fmt.Sprintf("Longest all-A-done streak: %d day(s)\n", st.LongestAStreak))
+
+	return s.String()
+}
+
+const statsBarWidth = 20
+
+func statsLine(label string, c completionCount, color lipgloss.TerminalColor, styled bool) string {
+	filled := int(c.Rate()*statsBarWidth + 0.5)
+	bar := strings.Repeat("█", filled)
+	empty := strings.Repeat("░", statsBarWidth-filled)
+	if styled {
+		bar = lipgloss.NewStyle().Foreground(color).Render(bar)
+		empty = helpStyle.Render(empty)
+	}
+	return fmt.Sprintf("  %-9s %s%s %3d/%-3d %3.0f%%\n", label, bar, empty, c.Done, c.Total, 100*c.Rate())
+}
+
+// formatDuration renders a duration as hours and minutes, e.g. "3h20m" or "45m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+}
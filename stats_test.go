@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStatsCompletion(t *testing.T) {
+	s := newTestStore(t)
+
+	// Monday 13 Jan: one of two done, plus a cancelled task that shouldn't count
+	s.AddTask("2025-01-13", "A1", PriorityA, "1h", "default")
+	s.AddTask("2025-01-13", "B1", PriorityB, "1h", "default")
+	s.AddTask("2025-01-13", "Dropped", PriorityB, "1h", "default")
+	// Tuesday 14 Jan: both done
+	s.AddTask("2025-01-14", "A2", PriorityA, "1h", "default")
+	s.AddTask("2025-01-14", "C1", PriorityC, "1h", "default")
+	// Before the since date, and in another context
+	s.AddTask("2025-01-01", "Old", PriorityA, "1h", "default")
+	s.AddTask("2025-01-13", "Work", PriorityA, "1h", "work")
+
+	for _, date := range []string{"2025-01-13", "2025-01-14"} {
+		tasks, _ := s.GetTasksForDate(date, "default")
+		for _, task := range tasks {
+			switch task.Description {
+			case "A1", "A2", "C1":
+				s.MarkComplete(task.ID)
+			case "Dropped":
+				s.MarkCancelled(task.ID)
+			}
+		}
+	}
+
+	st, err := s.GetStats("default", "2025-01-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(st.Days) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(st.Days))
+	}
+	if st.Days[0].Total != 2 || st.Days[0].Done != 1 {
+		t.Errorf("13th = %d/%d, want 1/2", st.Days[0].Done, st.Days[0].Total)
+	}
+	if st.Days[1].Rate() != 1 {
+		t.Errorf("14th rate = %v, want 1", st.Days[1].Rate())
+	}
+	if a := st.Priorities[PriorityA]; a.Total != 2 || a.Done != 2 {
+		t.Errorf("priority A = %d/%d, want 2/2", a.Done, a.Total)
+	}
+	if b := st.Priorities[PriorityB]; b.Total != 1 || b.Done != 0 {
+		t.Errorf("priority B = %d/%d, want 0/1", b.Done, b.Total)
+	}
+	if mon := st.Weekdays[time.Monday]; mon.Total != 2 {
+		t.Errorf("Monday total = %d, want 2", mon.Total)
+	}
+	if st.LongestAStreak != 2 {
+		t.Errorf("longest A streak = %d, want 2", st.LongestAStreak)
+	}
+}
+
+func TestGetStatsStreakBreaksOnMissedA(t *testing.T) {
+	s := newTestStore(t)
+
+	days := []struct {
+		date  string
+		aDone bool
+	}{
+		{"2025-01-13", true},
+		{"2025-01-14", false},
+		{"2025-01-15", true},
+		{"2025-01-16", true},
+		{"2025-01-17", true},
+	}
+	for _, d := range days {
+		id, _ := s.CreateTask(Task{Date: d.date, Description: "A", Priority: PriorityA, TimeEstimate: "1h", Context: "default"})
+		if d.aDone {
+			s.MarkComplete(id)
+		}
+	}
+	// A day with only B tasks doesn't break the streak
+	s.AddTask("2025-01-18", "B", PriorityB, "1h", "default")
+
+	st, err := s.GetStats("default", "2025-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.LongestAStreak != 3 {
+		t.Errorf("longest A streak = %d, want 3", st.LongestAStreak)
+	}
+}
+
+func TestGetStatsCarryAndEstimates(t *testing.T) {
+	s := newTestStore(t)
+	clock := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return clock }
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-13", Description: "Migrate DNS", Priority: PriorityA, TimeEstimate: "2h", Context: "default"})
+	s.AddTask("2025-01-13", "Never carried", PriorityB, "30m", "default")
+	s.MarkInProgress(id)
+
+	// A zero estimate can't be compared against, so it isn't sampled
+	zero, _ := s.CreateTask(Task{Date: "2025-01-13", Description: "Quick check", Priority: PriorityC, TimeEstimate: "0m", Context: "default"})
+	s.MarkInProgress(zero)
+	s.MarkComplete(zero)
+
+	// Carried twice before being finished three hours after it was started
+	first, _ := s.GetTask(id)
+	s.CarryOverTasks([]Task{first}, "2025-01-14", "default")
+	second, _ := s.GetTasksForDate("2025-01-14", "default")
+	s.CarryOverTasks(second, "2025-01-15", "default")
+	final, _ := s.GetTasksForDate("2025-01-15", "default")
+	clock = clock.Add(3 * time.Hour)
+	s.MarkComplete(final[0].ID)
+
+	st, err := s.GetStats("default", "2025-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Three lineage tips: the finished copy (carried twice) and the two uncarried tasks
+	if st.AverageCarryCount != 2.0/3 {
+		t.Errorf("average carry count = %v, want 2/3", st.AverageCarryCount)
+	}
+	if st.EstimateSamples != 1 || st.Estimated != 2*time.Hour || st.Actual != 3*time.Hour {
+		t.Errorf("estimate vs actual = %d samples, %v vs %v; want 1, 2h vs 3h", st.EstimateSamples, st.Estimated, st.Actual)
+	}
+}
+
+func TestRenderStats(t *testing.T) {
+	st := Stats{
+		Context:    "work",
+		Since:      "2025-01-13",
+		Days:       []dayStats{{Date: "2025-01-13", completionCount: completionCount{Total: 4, Done: 2}}},
+		Priorities: map[Priority]completionCount{PriorityA: {Total: 2, Done: 2}},
+	}
+	st.Weekdays[time.Monday] = completionCount{Total: 4, Done: 2}
+
+	out := renderStats(st, false)
+	for _, want := range []string{"· work", "Mon 13/01", "2/4", "50%", "A ", "100%", "Longest all-A-done streak: 0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+
+	st.EstimateSamples, st.Actual = 1, time.Hour
+	if out := renderStats(st, false); strings.Contains(out, "Inf") || strings.Contains(out, "NaN") {
+		t.Errorf("expected no percentage without an estimate, got:\n%s", out)
+	}
+}
+
+func TestRunStatsRejectsUnknownArgs(t *testing.T) {
+	s := newTestStore(t)
+	if err := runStats(s, []string{"--bogus"}); err == nil {
+		t.Error("expected error for unknown argument")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Minute, "45m"},
+		{2 * time.Hour, "2h"},
+		{3*time.Hour + 20*time.Minute, "3h20m"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
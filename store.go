@@ -3,29 +3,31 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 )
 
 type Store struct {
-	db *sql.DB
+	db    *sql.DB
+	path  string           // database file, for messages
+	now   func() time.Time // clock for timestamps; overridden in tests
+	hooks *hookRunner      // nil when no hooks are set up
 }
 
-// NewStore opens (or creates) the SQLite database at the platform config directory.
-func NewStore() (*Store, error) {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return nil, fmt.Errorf("config dir: %w", err)
-	}
-
-	dir := filepath.Join(configDir, "sysadmin-gtd")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+// NewStore opens (or creates) the SQLite database at path, creating its directory if needed.
+func NewStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("mkdir: %w", err)
 	}
-
-	return NewStoreWithPath(filepath.Join(dir, "tasks.db"))
+	return NewStoreWithPath(path)
 }
 
 // NewStoreWithPath opens a store at an explicit path (useful for tests with ":memory:").
@@ -35,7 +37,11 @@ func NewStoreWithPath(dsn string) (*Store, error) {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
 
-	s := &Store{db: db}
+	s := &Store{db: db, path: dsn, now: time.Now}
+	if err := s.snapshotBeforeMigrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, err
@@ -43,7 +49,135 @@ func NewStoreWithPath(dsn string) (*Store, error) {
 	return s, nil
 }
 
+// NewReadOnlyStore opens an existing database without writing to it: no migration, no
+// snapshot, and an error rather than a new file when there's nothing at path.
+func NewReadOnlyStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+(&url.URL{Path: path}).EscapedPath()+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	return &Store{db: db, path: path, now: time.Now}, nil
+}
+
+// resolveDBPath picks the database file: the --db flag, then $GTD_DB, then the default
+// location. When the default is used and a database still sits at the old config-directory
+// location, it is moved across first and movedFrom reports where it came from.
+func resolveDBPath(flag string) (path, movedFrom string, err error) {
+	path, legacy, err := locateDB(flag)
+	if err != nil || legacy == "" {
+		return path, "", err
+	}
+	if err := moveDB(legacy, path); err != nil {
+		return "", "", fmt.Errorf("move database from %s to %s: %w", legacy, path, err)
+	}
+	return path, legacy, nil
+}
+
+// locateDB is resolveDBPath without the move: legacy is set when the database still sits at
+// the old config-directory location and belongs at path.
+func locateDB(flag string) (path, legacy string, err error) {
+	if flag != "" {
+		return flag, "", nil
+	}
+	if env := os.Getenv("GTD_DB"); env != "" {
+		return env, "", nil
+	}
+
+	path, err = defaultDBPath()
+	if err != nil {
+		return "", "", err
+	}
+	legacy, err = legacyDBPath()
+	if err != nil || legacy == path {
+		return path, "", nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, "", nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return path, "", nil
+	}
+	return path, legacy, nil
+}
+
+// defaultDBPath is $XDG_DATA_HOME/sysadmin-gtd/tasks.db on Linux (~/.local/share when unset),
+// and the platform config directory elsewhere.
+func defaultDBPath() (string, error) {
+	if runtime.GOOS != "linux" {
+		return legacyDBPath()
+	}
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataDir) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("home dir: %w", err)
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "sysadmin-gtd", "tasks.db"), nil
+}
+
+// legacyDBPath is where every version before --db kept the database.
+func legacyDBPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("config dir: %w", err)
+	}
+	return filepath.Join(configDir, "sysadmin-gtd", "tasks.db"), nil
+}
+
+// moveDB moves a database file, and any SQLite journal files beside it, to a new path.
+// It copies when a rename isn't possible, e.g. across filesystems.
+func moveDB(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if _, err := os.Stat(from + suffix); err != nil {
+			continue
+		}
+		if err := moveFile(from+suffix, to+suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(to)
+		return err
+	}
+	src.Close()
+	return os.Remove(from)
+}
+
+// Close waits for any hooks still running, then closes the database.
 func (s *Store) Close() error {
+	s.hooks.wait()
 	return s.db.Close()
 }
 
@@ -55,7 +189,7 @@ func (s *Store) migrate() error {
 			description     TEXT    NOT NULL,
 			priority        TEXT    NOT NULL DEFAULT 'B',
 			time_estimate   TEXT    NOT NULL DEFAULT '',
-			is_completed    INTEGER NOT NULL DEFAULT 0,
+			status          INTEGER NOT NULL DEFAULT 0,
 			carried_from_id INTEGER REFERENCES tasks(id)
 		)
 	`)
@@ -63,15 +197,209 @@ func (s *Store) migrate() error {
 		return err
 	}
 
+	// Older databases stored the status in a column called is_completed.
+	legacy, err := s.hasColumn("tasks", "is_completed")
+	if err != nil {
+		return err
+	}
+	if legacy {
+		if _, err := s.db.Exec(`ALTER TABLE tasks RENAME COLUMN is_completed TO status`); err != nil {
+			return fmt.Errorf("rename is_completed: %w", err)
+		}
+	}
+
 	// Add context column for existing databases (ignored if already present).
 	s.db.Exec(`ALTER TABLE tasks ADD COLUMN context TEXT NOT NULL DEFAULT 'default'`)
 
-	return nil
+	// Add due_date column for existing databases (ignored if already present).
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN due_date TEXT NOT NULL DEFAULT ''`)
+
+	// Add audit timestamps for existing databases (ignored if already present).
+	// Rows created before these existed keep an empty value, meaning "unknown".
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN created_at TEXT NOT NULL DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN updated_at TEXT NOT NULL DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN completed_at TEXT NOT NULL DEFAULT ''`)
+
+	// Add notes column for existing databases (ignored if already present).
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN notes TEXT NOT NULL DEFAULT ''`)
+
+	// Add pomodoro count for existing databases (ignored if already present).
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN pomodoros INTEGER NOT NULL DEFAULT 0`)
+
+	// Add a stable unique ID for exports, and give one to every task that predates it.
+	s.db.Exec(`ALTER TABLE tasks ADD COLUMN uid TEXT NOT NULL DEFAULT ''`)
+	if err := s.assignMissingUIDs(); err != nil {
+		return fmt.Errorf("assign uids: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS tasks_uid ON tasks(uid)`); err != nil {
+		return err
+	}
+
+	if err := s.migrateSearchIndex(); err != nil {
+		return fmt.Errorf("search index: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS task_events (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id    INTEGER NOT NULL,
+			event      TEXT    NOT NULL,
+			detail     TEXT    NOT NULL DEFAULT '',
+			created_at TEXT    NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS task_events_task_id ON task_events(task_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Per-context settings. Contexts themselves still live on each task row; a context
+	// only gets a row here once it has something to record, such as being archived.
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS contexts (
+			name     TEXT    PRIMARY KEY,
+			archived INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Captured items waiting to be clarified, and those parked in the backlog; see inbox.go.
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS inbox (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			context    TEXT    NOT NULL,
+			text       TEXT    NOT NULL,
+			backlog    INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT    NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersion))
+	return err
+}
+
+// schemaVersion is stored in PRAGMA user_version once migrate has run. Bump it whenever
+// migrate changes the schema, so existing databases are snapshotted before they're upgraded.
+const schemaVersion = 2
+
+// snapshotBeforeMigrate takes a snapshot of an existing database whose schema is older than
+// this version of gtd, before migrate changes it.
+func (s *Store) snapshotBeforeMigrate() error {
+	var version, tables int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks'`).Scan(&tables); err != nil {
+		return err
+	}
+	if version >= schemaVersion || tables == 0 {
+		return nil
+	}
+	return s.snapshotBefore("migrate")
 }
 
+// assignMissingUIDs gives a UID to every task without one.
+func (s *Store) assignMissingUIDs() error {
+	rows, err := s.db.Query(`SELECT id FROM tasks WHERE uid = ''`)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return err
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec(`UPDATE tasks SET uid = ? WHERE id = ?`, uuid.NewString(), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateSearchIndex creates tasks_fts, an FTS5 index over task descriptions and notes. It is
+// an external-content table, so it stores only the index and reads the text from tasks; the
+// triggers keep it in step with every insert, update and delete.
+func (s *Store) migrateSearchIndex() error {
+	var exists int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts'`).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(
+			description, notes,
+			content = 'tasks', content_rowid = 'id',
+			tokenize = 'unicode61 remove_diacritics 2'
+		);
+		CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+			INSERT INTO tasks_fts (rowid, description, notes) VALUES (new.id, new.description, new.notes);
+		END;
+		CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+			INSERT INTO tasks_fts (tasks_fts, rowid, description, notes) VALUES ('delete', old.id, old.description, old.notes);
+		END;
+		CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF description, notes ON tasks BEGIN
+			INSERT INTO tasks_fts (tasks_fts, rowid, description, notes) VALUES ('delete', old.id, old.description, old.notes);
+			INSERT INTO tasks_fts (rowid, description, notes) VALUES (new.id, new.description, new.notes);
+		END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index the tasks already in a database created before the index existed.
+	if exists == 0 {
+		_, err = s.db.Exec(`INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild')`)
+	}
+	return err
+}
+
+func (s *Store) hasColumn(table, column string) (bool, error) {
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// openStatus matches tasks that still need attention; see Status for the carry-over rules.
+var openStatus = fmt.Sprintf("status NOT IN (%d, %d)", StatusDone, StatusCancelled)
+
+// taskColumns is the column list every task query selects, in the order scanTask expects.
+const taskColumns = `id, date, description, priority, time_estimate, status, carried_from_id, due_date,
+	created_at, updated_at, completed_at, context, notes, pomodoros, uid`
+
 func (s *Store) GetTasksForDate(date, context string) ([]Task, error) {
 	rows, err := s.db.Query(
-		`SELECT id, date, description, priority, time_estimate, is_completed, carried_from_id
+		`SELECT `+taskColumns+`
 		 FROM tasks WHERE date = ? AND context = ? ORDER BY priority, id`, date, context)
 	if err != nil {
 		return nil, err
@@ -81,72 +409,219 @@ func (s *Store) GetTasksForDate(date, context string) ([]Task, error) {
 	return scanTasks(rows)
 }
 
+// GetTasksDueBy returns unfinished tasks in a context whose due date is on or before dueBy,
+// regardless of the day they are planned for. Tasks that have since been carried forward are
+// skipped so each deadline is only reported once, on its latest copy.
+func (s *Store) GetTasksDueBy(dueBy, context string) ([]Task, error) {
+	rows, err := s.db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE context = ?
+		  AND due_date != ''
+		  AND due_date <= ?
+		  AND `+openStatus+`
+		  AND id NOT IN (
+			SELECT carried_from_id FROM tasks WHERE context = ? AND carried_from_id IS NOT NULL
+		  )
+		ORDER BY due_date, priority, id`, context, dueBy, context)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
 func (s *Store) AddTask(date, description string, priority Priority, timeEstimate, context string) error {
-	_, err := s.db.Exec(
-		`INSERT INTO tasks (date, description, priority, time_estimate, context) VALUES (?, ?, ?, ?, ?)`,
-		date, description, string(priority), timeEstimate, context)
+	_, err := s.CreateTask(Task{Date: date, Description: description, Priority: priority, TimeEstimate: timeEstimate, Context: context})
 	return err
 }
 
-func (s *Store) UpdateTask(id int64, description string, priority Priority, timeEstimate string) error {
-	_, err := s.db.Exec(
-		`UPDATE tasks SET description = ?, priority = ?, time_estimate = ? WHERE id = ?`,
-		description, string(priority), timeEstimate, id)
+// CreateTask inserts a new task from its fields and returns the new ID.
+func (s *Store) CreateTask(t Task) (int64, error) {
+	var id int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		id, err = s.createTask(tx, t, "")
+		return err
+	})
+	if err == nil {
+		s.fireTask("task.added", id)
+	}
+	return id, err
+}
+
+// createTask inserts t and logs its created event, with detail saying where it came from.
+// Callers fire task.added once the transaction commits.
+func (s *Store) createTask(tx *sql.Tx, t Task, detail string) (int64, error) {
+	id, err := s.insertTask(tx, t)
+	if err != nil {
+		return 0, err
+	}
+	return id, s.logEvent(tx, id, "created", detail)
+}
+
+func (s *Store) UpdateTask(id int64, description string, priority Priority, timeEstimate, dueDate, notes string) error {
+	changed := false
+	err := s.inTx(func(tx *sql.Tx) error {
+		old, err := scanTask(tx.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+		if err != nil {
+			return err
+		}
+
+		var changes []string
+		if old.Description != description {
+			changes = append(changes, "description")
+		}
+		if old.Priority != priority {
+			changes = append(changes, fmt.Sprintf("priority %s → %s", old.Priority, priority))
+		}
+		if old.TimeEstimate != timeEstimate {
+			changes = append(changes, fmt.Sprintf("estimate %s → %s", orNone(old.TimeEstimate), orNone(timeEstimate)))
+		}
+		if old.DueDate != dueDate {
+			changes = append(changes, fmt.Sprintf("due %s → %s", orNone(old.DueDate), orNone(dueDate)))
+		}
+		if old.Notes != notes {
+			changes = append(changes, "notes")
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(
+			`UPDATE tasks SET description = ?, priority = ?, time_estimate = ?, due_date = ?, notes = ?, updated_at = ? WHERE id = ?`,
+			description, string(priority), timeEstimate, dueDate, notes, s.timestamp(), id)
+		if err != nil {
+			return err
+		}
+		changed = true
+		return s.logEvent(tx, id, "edited", strings.Join(changes, ", "))
+	})
+	if err == nil && changed {
+		s.fireTask("task.edited", id)
+	}
+	return err
+}
+
+// MoveTask moves a task to another context.
+func (s *Store) MoveTask(id int64, context string) error {
+	changed := false
+	err := s.inTx(func(tx *sql.Tx) error {
+		var old string
+		if err := tx.QueryRow(`SELECT context FROM tasks WHERE id = ?`, id).Scan(&old); err != nil {
+			return err
+		}
+		if old == context {
+			return nil
+		}
+		if _, err := tx.Exec(`UPDATE tasks SET context = ?, updated_at = ? WHERE id = ?`, context, s.timestamp(), id); err != nil {
+			return err
+		}
+		changed = true
+		return s.logEvent(tx, id, "moved", fmt.Sprintf("context %s → %s", old, context))
+	})
+	if err == nil && changed {
+		s.fireTask("task.moved", id)
+	}
 	return err
 }
 
+// DeleteTask removes a task. Its events are kept so the audit log still shows the deletion.
 func (s *Store) DeleteTask(id int64) error {
-	_, err := s.db.Exec(`DELETE FROM tasks WHERE id = ?`, id)
+	if err := s.snapshotBefore("delete"); err != nil {
+		return err
+	}
+	var deleted Task
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		if deleted, err = scanTask(tx.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+			return err
+		}
+		return s.logEvent(tx, id, "deleted", deleted.Description)
+	})
+	if err == nil {
+		s.fireTaskValue("task.deleted", deleted)
+	}
 	return err
 }
 
-func (s *Store) MarkComplete(id int64) error {
-	_, err := s.db.Exec(`UPDATE tasks SET is_completed = 1 WHERE id = ?`, id)
+// SetStatus moves a task to a new status, stamping completed_at when it is marked done.
+func (s *Store) SetStatus(id int64, status Status) error {
+	changed := false
+	err := s.inTx(func(tx *sql.Tx) error {
+		var old int
+		if err := tx.QueryRow(`SELECT status FROM tasks WHERE id = ?`, id).Scan(&old); err != nil {
+			return err
+		}
+		if Status(old) == status {
+			return nil
+		}
+
+		now := s.timestamp()
+		completedAt := ""
+		if status == StatusDone {
+			completedAt = now
+		}
+		_, err := tx.Exec(`UPDATE tasks SET status = ?, updated_at = ?, completed_at = ? WHERE id = ?`,
+			int(status), now, completedAt, id)
+		if err != nil {
+			return err
+		}
+		changed = true
+		return s.logEvent(tx, id, statusEvent(status), "was "+Status(old).Name())
+	})
+	if err == nil && changed {
+		s.fireTask(hookStatusEvents[status], id)
+	}
 	return err
 }
 
+func (s *Store) MarkComplete(id int64) error {
+	return s.SetStatus(id, StatusDone)
+}
+
 func (s *Store) MarkIncomplete(id int64) error {
-	_, err := s.db.Exec(`UPDATE tasks SET is_completed = 0 WHERE id = ?`, id)
-	return err
+	return s.SetStatus(id, StatusTodo)
 }
 
 func (s *Store) MarkInProgress(id int64) error {
-	_, err := s.db.Exec(`UPDATE tasks SET is_completed = 2 WHERE id = ?`, id)
-	return err
+	return s.SetStatus(id, StatusInProgress)
 }
 
-func (s *Store) GetTask(id int64) (Task, error) {
-	row := s.db.QueryRow(
-		`SELECT id, date, description, priority, time_estimate, is_completed, carried_from_id
-		 FROM tasks WHERE id = ?`, id)
+func (s *Store) MarkCancelled(id int64) error {
+	return s.SetStatus(id, StatusCancelled)
+}
 
-	var t Task
-	var carriedFromID sql.NullInt64
-	var status int
-	err := row.Scan(&t.ID, &t.Date, &t.Description, &t.Priority, &t.TimeEstimate, &status, &carriedFromID)
-	if err != nil {
-		return Task{}, err
-	}
-	t.Status = Status(status)
-	if carriedFromID.Valid {
-		t.CarriedFromID = &carriedFromID.Int64
-	}
-	return t, nil
+func (s *Store) MarkBlocked(id int64) error {
+	return s.SetStatus(id, StatusBlocked)
+}
+
+func (s *Store) MarkDelegated(id int64) error {
+	return s.SetStatus(id, StatusDelegated)
 }
 
-// GetCarryOverCandidates returns incomplete tasks for fromDate that haven't already
-// been carried over to the next day.
+func (s *Store) GetTask(id int64) (Task, error) {
+	row := s.db.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id)
+	return scanTask(row)
+}
+
+// GetCarryOverCandidates returns open tasks (todo, in progress, blocked or delegated) for
+// fromDate that haven't already been carried over to the next day.
 func (s *Store) GetCarryOverCandidates(fromDate, toDate, context string) ([]Task, error) {
 	rows, err := s.db.Query(`
-		SELECT t.id, t.date, t.description, t.priority, t.time_estimate, t.is_completed, t.carried_from_id
-		FROM tasks t
-		WHERE t.date = ?
-		  AND t.context = ?
-		  AND t.is_completed != 1
-		  AND t.id NOT IN (
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE date = ?
+		  AND context = ?
+		  AND `+openStatus+`
+		  AND id NOT IN (
 			SELECT carried_from_id FROM tasks WHERE date = ? AND context = ? AND carried_from_id IS NOT NULL
 		  )
-		ORDER BY t.priority, t.id`, fromDate, context, toDate, context)
+		ORDER BY priority, id`, fromDate, context, toDate, context)
 	if err != nil {
 		return nil, err
 	}
@@ -157,26 +632,45 @@ func (s *Store) GetCarryOverCandidates(fromDate, toDate, context string) ([]Task
 
 // CarryOverTasks creates copies of the given tasks for toDate, setting carried_from_id.
 func (s *Store) CarryOverTasks(tasks []Task, toDate, context string) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
+	if len(tasks) == 0 {
+		return nil
 	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(
-		`INSERT INTO tasks (date, description, priority, time_estimate, is_completed, carried_from_id, context) VALUES (?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
+	if err := s.snapshotBefore("carry"); err != nil {
 		return err
 	}
-	defer stmt.Close()
-
-	for _, t := range tasks {
-		if _, err := stmt.Exec(toDate, t.Description, string(t.Priority), t.TimeEstimate, int(t.Status), t.ID, context); err != nil {
-			return err
+	var ids []int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		for _, t := range tasks {
+			fromID := t.ID
+			id, err := s.insertTask(tx, Task{
+				Date:          toDate,
+				Description:   t.Description,
+				Priority:      t.Priority,
+				TimeEstimate:  t.TimeEstimate,
+				Status:        t.Status,
+				CarriedFromID: &fromID,
+				DueDate:       t.DueDate,
+				Notes:         t.Notes,
+				Pomodoros:     t.Pomodoros,
+				Context:       context,
+			})
+			if err != nil {
+				return err
+			}
+			if err := s.logEvent(tx, id, "created", "carried from "+t.Date); err != nil {
+				return err
+			}
+			if err := s.logEvent(tx, t.ID, "carried", "to "+toDate); err != nil {
+				return err
+			}
+			ids = append(ids, id)
 		}
+		return nil
+	})
+	if err == nil {
+		s.fireCarried(ids, toDate, context)
 	}
-
-	return tx.Commit()
+	return err
 }
 
 // GetLatestDateWithIncompleteTasks returns the most recent date before the given date
@@ -184,7 +678,7 @@ func (s *Store) CarryOverTasks(tasks []Task, toDate, context string) error {
 func (s *Store) GetLatestDateWithIncompleteTasks(beforeDate, context string) (string, error) {
 	var date string
 	err := s.db.QueryRow(
-		`SELECT date FROM tasks WHERE date < ? AND context = ? AND is_completed != 1 GROUP BY date ORDER BY date DESC LIMIT 1`,
+		`SELECT date FROM tasks WHERE date < ? AND context = ? AND `+openStatus+` GROUP BY date ORDER BY date DESC LIMIT 1`,
 		beforeDate, context).Scan(&date)
 	if err == sql.ErrNoRows {
 		return "", nil
@@ -192,29 +686,207 @@ func (s *Store) GetLatestDateWithIncompleteTasks(beforeDate, context string) (st
 	return date, err
 }
 
-// CopyIncompleteTasks copies incomplete tasks from one date to another.
+// CopyIncompleteTasks copies open tasks from one date to another.
 func (s *Store) CopyIncompleteTasks(fromDate, toDate, context string) error {
-	_, err := s.db.Exec(`
-		INSERT INTO tasks (date, description, priority, time_estimate, is_completed, context)
-		SELECT ?, description, priority, time_estimate, is_completed, context
-		FROM tasks WHERE date = ? AND context = ? AND is_completed != 1 ORDER BY priority, id`,
-		toDate, fromDate, context)
+	if err := s.snapshotBefore("import"); err != nil {
+		return err
+	}
+	var ids []int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(
+			`SELECT `+taskColumns+` FROM tasks WHERE date = ? AND context = ? AND `+openStatus+` ORDER BY priority, id`,
+			fromDate, context)
+		if err != nil {
+			return err
+		}
+		tasks, err := scanTasks(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tasks {
+			id, err := s.createTask(tx, Task{
+				Date:         toDate,
+				Description:  t.Description,
+				Priority:     t.Priority,
+				TimeEstimate: t.TimeEstimate,
+				Status:       t.Status,
+				DueDate:      t.DueDate,
+				Notes:        t.Notes,
+				Context:      context,
+			}, "imported from "+fromDate)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err == nil {
+		for _, id := range ids {
+			s.fireTask("task.added", id)
+		}
+	}
+	return err
+}
+
+// GetTaskEvents returns the audit log for a task, including the events of the earlier copies
+// it was carried over from, oldest first.
+func (s *Store) GetTaskEvents(id int64) ([]TaskEvent, error) {
+	rows, err := s.db.Query(`
+		WITH RECURSIVE lineage(id) AS (
+			SELECT ?
+			UNION
+			SELECT t.carried_from_id FROM tasks t JOIN lineage l ON t.id = l.id WHERE t.carried_from_id IS NOT NULL
+		)
+		SELECT id, task_id, event, detail, created_at
+		FROM task_events
+		WHERE task_id IN (SELECT id FROM lineage)
+		ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []TaskEvent
+	for rows.Next() {
+		var e TaskEvent
+		var at string
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.Event, &e.Detail, &at); err != nil {
+			return nil, err
+		}
+		e.At = parseTimestamp(at)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// insertTask writes a new row for t. Timestamps and UID already set on t are kept; missing
+// timestamps are stamped with the current time and a missing UID is generated.
+func (s *Store) insertTask(tx *sql.Tx, t Task) (int64, error) {
+	now := s.timestamp()
+	createdAt, updatedAt, completedAt := now, now, ""
+	if !t.CreatedAt.IsZero() {
+		createdAt = formatTimestamp(t.CreatedAt)
+	}
+	if !t.UpdatedAt.IsZero() {
+		updatedAt = formatTimestamp(t.UpdatedAt)
+	}
+	if !t.CompletedAt.IsZero() {
+		completedAt = formatTimestamp(t.CompletedAt)
+	} else if t.Status == StatusDone {
+		completedAt = now
+	}
+	uid := t.UID
+	if uid == "" {
+		uid = uuid.NewString()
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO tasks (date, description, priority, time_estimate, status, carried_from_id, due_date, context,
+			notes, pomodoros, uid, created_at, updated_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.Date, t.Description, string(t.Priority), t.TimeEstimate, int(t.Status), t.CarriedFromID, t.DueDate, t.Context,
+		t.Notes, t.Pomodoros, uid, createdAt, updatedAt, completedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *Store) logEvent(tx *sql.Tx, taskID int64, event, detail string) error {
+	_, err := tx.Exec(`INSERT INTO task_events (task_id, event, detail, created_at) VALUES (?, ?, ?, ?)`,
+		taskID, event, detail, s.timestamp())
 	return err
 }
 
+// inTx runs fn in a transaction, committing only if it succeeds.
+func (s *Store) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) timestamp() string {
+	return formatTimestamp(s.now())
+}
+
+// Timestamps are stored as RFC 3339 text in UTC so they sort correctly as strings.
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseTimestamp returns the zero time for empty or unparseable values.
+func parseTimestamp(v string) time.Time {
+	t, _ := time.Parse(time.RFC3339, v)
+	return t
+}
+
+// statusEvent names the audit event recorded when a task moves to the given status.
+func statusEvent(s Status) string {
+	switch s {
+	case StatusDone:
+		return "completed"
+	case StatusInProgress:
+		return "started"
+	case StatusCancelled:
+		return "cancelled"
+	case StatusBlocked:
+		return "blocked"
+	case StatusDelegated:
+		return "delegated"
+	default:
+		return "reopened"
+	}
+}
+
+func orNone(v string) string {
+	if v == "" {
+		return "none"
+	}
+	return v
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask reads one row selected with taskColumns.
+func scanTask(row rowScanner) (Task, error) {
+	var t Task
+	var carriedFromID sql.NullInt64
+	var status int
+	var createdAt, updatedAt, completedAt string
+	if err := row.Scan(&t.ID, &t.Date, &t.Description, &t.Priority, &t.TimeEstimate, &status, &carriedFromID, &t.DueDate,
+		&createdAt, &updatedAt, &completedAt, &t.Context, &t.Notes, &t.Pomodoros, &t.UID); err != nil {
+		return Task{}, err
+	}
+	t.Status = Status(status)
+	t.CreatedAt = parseTimestamp(createdAt)
+	t.UpdatedAt = parseTimestamp(updatedAt)
+	t.CompletedAt = parseTimestamp(completedAt)
+	if carriedFromID.Valid {
+		t.CarriedFromID = &carriedFromID.Int64
+	}
+	return t, nil
+}
+
 func scanTasks(rows *sql.Rows) ([]Task, error) {
 	var tasks []Task
 	for rows.Next() {
-		var t Task
-		var carriedFromID sql.NullInt64
-		var status int
-		if err := rows.Scan(&t.ID, &t.Date, &t.Description, &t.Priority, &t.TimeEstimate, &status, &carriedFromID); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
-		t.Status = Status(status)
-		if carriedFromID.Valid {
-			t.CarriedFromID = &carriedFromID.Int64
-		}
 		tasks = append(tasks, t)
 	}
 	return tasks, rows.Err()
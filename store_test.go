@@ -1,7 +1,13 @@
 package main
 
 import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func newTestStore(t *testing.T) *Store {
@@ -51,7 +57,7 @@ func TestUpdateTask(t *testing.T) {
 	tasks, _ := s.GetTasksForDate("2025-01-15", "default")
 	id := tasks[0].ID
 
-	if err := s.UpdateTask(id, "Updated", PriorityA, "2h"); err != nil {
+	if err := s.UpdateTask(id, "Updated", PriorityA, "2h", "", "Check with the vendor first"); err != nil {
 		t.Fatal(err)
 	}
 
@@ -68,6 +74,9 @@ func TestUpdateTask(t *testing.T) {
 	if task.TimeEstimate != "2h" {
 		t.Errorf("time_estimate = %q, want %q", task.TimeEstimate, "2h")
 	}
+	if task.Notes != "Check with the vendor first" {
+		t.Errorf("notes = %q, want %q", task.Notes, "Check with the vendor first")
+	}
 }
 
 func TestDeleteTask(t *testing.T) {
@@ -397,3 +406,302 @@ func TestCopyIncompleteTasksPreservesInProgress(t *testing.T) {
 		t.Errorf("expected in-progress status to be preserved, got %d", copied[0].Status)
 	}
 }
+
+func TestGetTasksDueBy(t *testing.T) {
+	s := newTestStore(t)
+
+	s.CreateTask(Task{Date: "2025-01-10", Description: "Due soon", Priority: PriorityB, TimeEstimate: "1h", DueDate: "2025-01-16", Context: "default"})
+	s.CreateTask(Task{Date: "2025-01-20", Description: "Planned later, due soon", Priority: PriorityA, TimeEstimate: "1h", DueDate: "2025-01-15", Context: "default"})
+	s.CreateTask(Task{Date: "2025-01-10", Description: "Due later", Priority: PriorityB, TimeEstimate: "1h", DueDate: "2025-02-01", Context: "default"})
+	s.CreateTask(Task{Date: "2025-01-10", Description: "No deadline", Priority: PriorityB, TimeEstimate: "1h", Context: "default"})
+	s.CreateTask(Task{Date: "2025-01-10", Description: "Other context", Priority: PriorityB, TimeEstimate: "1h", DueDate: "2025-01-15", Context: "work"})
+	doneID, _ := s.CreateTask(Task{Date: "2025-01-10", Description: "Already done", Priority: PriorityB, TimeEstimate: "1h", DueDate: "2025-01-15", Context: "default"})
+	s.MarkComplete(doneID)
+
+	tasks, err := s.GetTasksDueBy("2025-01-17", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 due tasks, got %d", len(tasks))
+	}
+	// Ordered by due date first
+	if tasks[0].Description != "Planned later, due soon" {
+		t.Errorf("expected earliest deadline first, got %q", tasks[0].Description)
+	}
+}
+
+func TestGetTasksDueByReportsLatestCarriedCopy(t *testing.T) {
+	s := newTestStore(t)
+
+	s.CreateTask(Task{Date: "2025-01-15", Description: "Carried deadline", Priority: PriorityA, TimeEstimate: "1h", DueDate: "2025-01-17", Context: "default"})
+	tasks, _ := s.GetTasksForDate("2025-01-15", "default")
+	s.CarryOverTasks(tasks, "2025-01-16", "default")
+
+	due, err := s.GetTasksDueBy("2025-01-17", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("expected 1 due task, got %d", len(due))
+	}
+	if due[0].Date != "2025-01-16" {
+		t.Errorf("expected the carried copy on 2025-01-16, got %q", due[0].Date)
+	}
+	if due[0].DueDate != "2025-01-17" {
+		t.Errorf("carried copy should keep its due date, got %q", due[0].DueDate)
+	}
+}
+
+func TestCarryOverRulesForEachStatus(t *testing.T) {
+	s := newTestStore(t)
+
+	statuses := map[string]Status{
+		"todo":      StatusTodo,
+		"wip":       StatusInProgress,
+		"done":      StatusDone,
+		"cancelled": StatusCancelled,
+		"blocked":   StatusBlocked,
+		"delegated": StatusDelegated,
+	}
+	for desc, status := range statuses {
+		id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: desc, Priority: PriorityB, TimeEstimate: "1h", Context: "default"})
+		s.SetStatus(id, status)
+	}
+
+	candidates, err := s.GetCarryOverCandidates("2025-01-15", "2025-01-16", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	carried := map[string]bool{}
+	for _, c := range candidates {
+		carried[c.Description] = true
+	}
+	for desc, status := range statuses {
+		if carried[desc] == status.IsClosed() {
+			t.Errorf("%s task: carried = %v, want %v", desc, carried[desc], !status.IsClosed())
+		}
+	}
+
+	s.CarryOverTasks(candidates, "2025-01-16", "default")
+	next, _ := s.GetTasksForDate("2025-01-16", "default")
+	for _, c := range next {
+		if c.Status != statuses[c.Description] {
+			t.Errorf("%s task: status after carry = %d, want %d", c.Description, c.Status, statuses[c.Description])
+		}
+	}
+}
+
+func TestCancelledTasksAreNotImported(t *testing.T) {
+	s := newTestStore(t)
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: "Won't do", Priority: PriorityB, TimeEstimate: "1h", Context: "default"})
+	s.MarkCancelled(id)
+
+	date, err := s.GetLatestDateWithIncompleteTasks("2025-01-20", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date != "" {
+		t.Errorf("expected no date with open tasks, got %q", date)
+	}
+}
+
+func TestMigrateRenamesLegacyStatusColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE tasks (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			date            TEXT    NOT NULL,
+			description     TEXT    NOT NULL,
+			priority        TEXT    NOT NULL DEFAULT 'B',
+			time_estimate   TEXT    NOT NULL DEFAULT '',
+			is_completed    INTEGER NOT NULL DEFAULT 0,
+			carried_from_id INTEGER REFERENCES tasks(id)
+		);
+		INSERT INTO tasks (date, description, is_completed) VALUES ('2025-01-15', 'Old task', 2);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStoreWithPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	tasks, err := s.GetTasksForDate("2025-01-15", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Status != StatusInProgress {
+		t.Fatalf("expected legacy in-progress task to survive migration, got %+v", tasks)
+	}
+}
+
+func TestAuditTimestamps(t *testing.T) {
+	s := newTestStore(t)
+	clock := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return clock }
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: "Patch servers", Priority: PriorityA, TimeEstimate: "2h", Context: "default"})
+
+	task, _ := s.GetTask(id)
+	if !task.CreatedAt.Equal(clock) || !task.UpdatedAt.Equal(clock) {
+		t.Errorf("created/updated = %v/%v, want %v", task.CreatedAt, task.UpdatedAt, clock)
+	}
+	if !task.CompletedAt.IsZero() {
+		t.Errorf("new task should have no completed_at, got %v", task.CompletedAt)
+	}
+
+	clock = clock.Add(3 * time.Hour)
+	s.MarkComplete(id)
+	task, _ = s.GetTask(id)
+	if !task.CompletedAt.Equal(clock) || !task.UpdatedAt.Equal(clock) {
+		t.Errorf("completed/updated = %v/%v, want %v", task.CompletedAt, task.UpdatedAt, clock)
+	}
+
+	s.MarkIncomplete(id)
+	task, _ = s.GetTask(id)
+	if !task.CompletedAt.IsZero() {
+		t.Errorf("reopened task should have no completed_at, got %v", task.CompletedAt)
+	}
+}
+
+func TestTaskEventsRecordEveryMutation(t *testing.T) {
+	s := newTestStore(t)
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: "Patch servers", Priority: PriorityB, TimeEstimate: "2h", Context: "default"})
+	s.MarkInProgress(id)
+	s.UpdateTask(id, "Patch servers", PriorityA, "3h", "", "")
+	s.MarkComplete(id)
+
+	events, err := s.GetTaskEvents(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"created", "started", "edited", "completed"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, e := range events {
+		if e.Event != want[i] {
+			t.Errorf("event %d = %q, want %q", i, e.Event, want[i])
+		}
+	}
+	if events[2].Detail != "priority B → A, estimate 2h → 3h" {
+		t.Errorf("edit detail = %q", events[2].Detail)
+	}
+	if events[3].Detail != "was in progress" {
+		t.Errorf("status detail = %q", events[3].Detail)
+	}
+
+	s.DeleteTask(id)
+	events, _ = s.GetTaskEvents(id)
+	if last := events[len(events)-1]; last.Event != "deleted" || last.Detail != "Patch servers" {
+		t.Errorf("expected deleted event to be kept, got %+v", last)
+	}
+}
+
+func TestUnchangedUpdateRecordsNoEvent(t *testing.T) {
+	s := newTestStore(t)
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: "Same", Priority: PriorityB, TimeEstimate: "1h", Context: "default"})
+	s.UpdateTask(id, "Same", PriorityB, "1h", "", "")
+	s.MarkIncomplete(id)
+
+	events, _ := s.GetTaskEvents(id)
+	if len(events) != 1 {
+		t.Errorf("expected only the created event, got %+v", events)
+	}
+}
+
+func TestTaskEventsFollowCarryLineage(t *testing.T) {
+	s := newTestStore(t)
+
+	id, _ := s.CreateTask(Task{Date: "2025-01-15", Description: "Renew cert", Priority: PriorityA, TimeEstimate: "1h", Context: "default"})
+	s.MarkInProgress(id)
+	tasks, _ := s.GetTasksForDate("2025-01-15", "default")
+	s.CarryOverTasks(tasks, "2025-01-16", "default")
+
+	carried, _ := s.GetTasksForDate("2025-01-16", "default")
+	events, err := s.GetTaskEvents(carried[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Event)
+	}
+	got := strings.Join(names, ",")
+	if got != "created,started,created,carried" {
+		t.Errorf("events = %s, want created,started,created,carried", got)
+	}
+}
+
+func TestResolveDBPathOverrides(t *testing.T) {
+	t.Setenv("GTD_DB", "/tmp/from-env.db")
+
+	path, moved, err := resolveDBPath("/tmp/from-flag.db")
+	if err != nil || path != "/tmp/from-flag.db" || moved != "" {
+		t.Errorf("flag: got %q, %q, %v", path, moved, err)
+	}
+
+	path, moved, err = resolveDBPath("")
+	if err != nil || path != "/tmp/from-env.db" || moved != "" {
+		t.Errorf("env: got %q, %q, %v", path, moved, err)
+	}
+}
+
+func TestResolveDBPathMovesLegacyDatabase(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the default location only differs from the old one on Linux")
+	}
+	configHome, dataHome := t.TempDir(), t.TempDir()
+	t.Setenv("GTD_DB", "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	legacy := filepath.Join(configHome, "sysadmin-gtd", "tasks.db")
+	s, err := NewStore(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTask("2025-01-15", "Survives the move", PriorityA, "1h", "default")
+	s.Close()
+
+	path, moved, err := resolveDBPath("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dataHome, "sysadmin-gtd", "tasks.db"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if moved != legacy {
+		t.Errorf("movedFrom = %q, want %q", moved, legacy)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("old database should be gone")
+	}
+
+	s, err = NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if tasks, _ := s.GetTasksForDate("2025-01-15", "default"); len(tasks) != 1 {
+		t.Errorf("expected the moved database to keep its task, got %d", len(tasks))
+	}
+
+	// Only happens once
+	if _, moved, _ := resolveDBPath(""); moved != "" {
+		t.Errorf("expected no second move, got %q", moved)
+	}
+}
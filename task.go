@@ -1,6 +1,12 @@
 package main
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -30,18 +36,17 @@ func (p Priority) Label() string {
 	}
 }
 
-func (p Priority) Color() lipgloss.Color {
+// Color is the priority's colour in the active theme.
+func (p Priority) Color() lipgloss.TerminalColor {
 	switch p {
 	case PriorityA:
-		return lipgloss.Color("#ef4444") // red
+		return theme.PriorityA
 	case PriorityB:
-		return lipgloss.Color("#f97316") // orange
+		return theme.PriorityB
 	case PriorityC:
-		return lipgloss.Color("#0ea5e9") // sky
-	case PriorityD:
-		return lipgloss.Color("#a1a1aa") // zinc
+		return theme.PriorityC
 	default:
-		return lipgloss.Color("#a1a1aa")
+		return theme.PriorityD
 	}
 }
 
@@ -55,12 +60,19 @@ func PriorityOptions() []huh.Option[Priority] {
 }
 
 // Status represents task completion state.
+//
+// Done and Cancelled are closed: they are never carried over or imported. Todo, In progress,
+// Blocked and Delegated are open and carry forward with their status intact, so blocked work
+// and work handed to someone else keeps turning up until it is followed through.
 type Status int
 
 const (
 	StatusTodo       Status = 0
 	StatusDone       Status = 1
 	StatusInProgress Status = 2
+	StatusCancelled  Status = 3
+	StatusBlocked    Status = 4
+	StatusDelegated  Status = 5
 )
 
 func (s Status) Symbol() string {
@@ -69,6 +81,12 @@ func (s Status) Symbol() string {
 		return "▶"
 	case StatusDone:
 		return "✓"
+	case StatusCancelled:
+		return "✗"
+	case StatusBlocked:
+		return "⊘"
+	case StatusDelegated:
+		return "→"
 	default:
 		return ""
 	}
@@ -80,11 +98,40 @@ func (s Status) PrintLabel() string {
 		return "WIP"
 	case StatusDone:
 		return "Yes"
+	case StatusCancelled:
+		return "Cancelled"
+	case StatusBlocked:
+		return "Blocked"
+	case StatusDelegated:
+		return "Delegated"
 	default:
 		return ""
 	}
 }
 
+// Name is the lowercase status name used in audit logs.
+func (s Status) Name() string {
+	switch s {
+	case StatusInProgress:
+		return "in progress"
+	case StatusDone:
+		return "done"
+	case StatusCancelled:
+		return "cancelled"
+	case StatusBlocked:
+		return "blocked"
+	case StatusDelegated:
+		return "delegated"
+	default:
+		return "todo"
+	}
+}
+
+// IsClosed reports whether a task needs no further attention (done or won't do).
+func (s Status) IsClosed() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
 // Task represents a single to-do item for a specific day.
 type Task struct {
 	ID            int64
@@ -94,6 +141,23 @@ type Task struct {
 	TimeEstimate  string
 	Status        Status
 	CarriedFromID *int64
+	DueDate       string // yyyy-mm-dd, or "" when there is no deadline
+	Context       string
+	Notes         string // free text shown in the task's history, and searched with the description
+	Pomodoros     int    // focus intervals completed, kept when the task is carried over
+	UID           string // globally unique, for exports; each carried copy gets its own
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	CompletedAt   time.Time // zero unless the task is done
+}
+
+// TaskEvent is one entry in a task's audit log, e.g. "started" or "edited".
+type TaskEvent struct {
+	ID     int64
+	TaskID int64
+	Event  string
+	Detail string
+	At     time.Time
 }
 
 func (t Task) WasCarriedOver() bool {
@@ -101,16 +165,140 @@ func (t Task) WasCarriedOver() bool {
 }
 
 func (t Task) DisplayDescription() string {
+	var notes []string
 	if t.WasCarriedOver() {
-		return t.Description + " (carried over)"
+		notes = append(notes, "carried over")
+	}
+	switch {
+	case t.Pomodoros == 1:
+		notes = append(notes, "1 pomodoro")
+	case t.Pomodoros > 1:
+		notes = append(notes, fmt.Sprintf("%d pomodoros", t.Pomodoros))
+	}
+	if len(notes) == 0 {
+		return t.Description
+	}
+	return t.Description + " (" + strings.Join(notes, ", ") + ")"
+}
+
+// Tags are the #hashtags in the description, lower-cased and without the #.
+func (t Task) Tags() []string {
+	var tags []string
+	for _, word := range strings.Fields(t.Description) {
+		if len(word) < 2 || word[0] != '#' {
+			continue
+		}
+		tag := strings.TrimRightFunc(word[1:], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		})
+		if tag != "" {
+			tags = append(tags, strings.ToLower(tag))
+		}
 	}
-	return t.Description
+	return tags
 }
 
 func (t Task) DoneDisplay() string {
 	return t.Status.PrintLabel()
 }
 
+func (t Task) HasDueDate() bool {
+	return t.DueDate != ""
+}
+
+// IsOverdue reports whether an unfinished task's deadline has passed as of today (yyyy-mm-dd).
+func (t Task) IsOverdue(today string) bool {
+	return t.HasDueDate() && !t.Status.IsClosed() && t.DueDate < today
+}
+
+// IsDueOn reports whether an unfinished task's deadline falls on the given day (yyyy-mm-dd).
+func (t Task) IsDueOn(today string) bool {
+	return t.HasDueDate() && !t.Status.IsClosed() && t.DueDate == today
+}
+
+// DueDisplay renders the deadline for the table, flagging tasks that are due today or overdue.
+func (t Task) DueDisplay(today string) string {
+	if !t.HasDueDate() {
+		return ""
+	}
+	switch {
+	case t.IsOverdue(today):
+		return "! overdue"
+	case t.IsDueOn(today):
+		return "! today"
+	}
+	d, err := time.Parse("2006-01-02", t.DueDate)
+	if err != nil {
+		return t.DueDate
+	}
+	return d.Format(config.shortDateLayout())
+}
+
+// parseEstimate reads a free-text time estimate such as "30m", "2h", "1h30m" or "1d".
+// A bare number is taken as minutes. ok is false when the estimate can't be understood.
+func parseEstimate(s string) (d time.Duration, ok bool) {
+	s = strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	if s == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Minute, n >= 0
+	}
+
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, false
+		}
+		var unit time.Duration
+		switch s[i] {
+		case 'm':
+			unit = time.Minute
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = config.workingDay()
+		default:
+			return 0, false
+		}
+		d += time.Duration(n * float64(unit))
+		s = s[i+1:]
+	}
+	return d, true
+}
+
+// completionSummary describes progress on a list of tasks, e.g. "3/5 tasks completed, 1 in progress".
+// Cancelled tasks don't count towards the total.
+func completionSummary(tasks []Task) string {
+	count := func(s Status) int {
+		return len(filterTasks(tasks, func(t Task) bool { return t.Status == s }))
+	}
+
+	cancelled := count(StatusCancelled)
+	summary := fmt.Sprintf("%d/%d tasks completed", count(StatusDone), len(tasks)-cancelled)
+	for _, part := range []struct {
+		n     int
+		label string
+	}{
+		{count(StatusInProgress), "in progress"},
+		{count(StatusBlocked), "blocked"},
+		{count(StatusDelegated), "delegated"},
+		{cancelled, "cancelled"},
+	} {
+		if part.n > 0 {
+			summary += fmt.Sprintf(", %d %s", part.n, part.label)
+		}
+	}
+	return summary
+}
+
 func filterTasks(tasks []Task, predicate func(Task) bool) []Task {
 	var result []Task
 	for _, t := range tasks {
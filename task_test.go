@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -64,6 +65,11 @@ func TestDisplayDescription(t *testing.T) {
 	if got := carried.DisplayDescription(); got != "do laundry (carried over)" {
 		t.Errorf("got %q, want %q", got, "do laundry (carried over)")
 	}
+
+	focused := Task{Description: "do laundry", CarriedFromID: &id, Pomodoros: 2}
+	if got := focused.DisplayDescription(); got != "do laundry (carried over, 2 pomodoros)" {
+		t.Errorf("got %q, want %q", got, "do laundry (carried over, 2 pomodoros)")
+	}
 }
 
 func TestDoneDisplay(t *testing.T) {
@@ -91,6 +97,9 @@ func TestStatusSymbol(t *testing.T) {
 		{StatusTodo, ""},
 		{StatusInProgress, "▶"},
 		{StatusDone, "✓"},
+		{StatusCancelled, "✗"},
+		{StatusBlocked, "⊘"},
+		{StatusDelegated, "→"},
 	}
 	for _, tt := range tests {
 		if got := tt.s.Symbol(); got != tt.want {
@@ -107,6 +116,9 @@ func TestStatusPrintLabel(t *testing.T) {
 		{StatusTodo, ""},
 		{StatusInProgress, "WIP"},
 		{StatusDone, "Yes"},
+		{StatusCancelled, "Cancelled"},
+		{StatusBlocked, "Blocked"},
+		{StatusDelegated, "Delegated"},
 	}
 	for _, tt := range tests {
 		if got := tt.s.PrintLabel(); got != tt.want {
@@ -115,6 +127,49 @@ func TestStatusPrintLabel(t *testing.T) {
 	}
 }
 
+func TestStatusIsClosed(t *testing.T) {
+	tests := []struct {
+		s    Status
+		want bool
+	}{
+		{StatusTodo, false},
+		{StatusInProgress, false},
+		{StatusBlocked, false},
+		{StatusDelegated, false},
+		{StatusDone, true},
+		{StatusCancelled, true},
+	}
+	for _, tt := range tests {
+		if got := tt.s.IsClosed(); got != tt.want {
+			t.Errorf("Status(%d).IsClosed() = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []Task
+		want  string
+	}{
+		{"empty", nil, "0/0 tasks completed"},
+		{"todo and done", []Task{{Status: StatusDone}, {Status: StatusTodo}}, "1/2 tasks completed"},
+		{"cancelled excluded from total", []Task{{Status: StatusDone}, {Status: StatusCancelled}}, "1/1 tasks completed, 1 cancelled"},
+		{"all statuses", []Task{
+			{Status: StatusDone},
+			{Status: StatusInProgress},
+			{Status: StatusBlocked},
+			{Status: StatusDelegated},
+			{Status: StatusCancelled},
+		}, "1/4 tasks completed, 1 in progress, 1 blocked, 1 delegated, 1 cancelled"},
+	}
+	for _, tt := range tests {
+		if got := completionSummary(tt.tasks); got != tt.want {
+			t.Errorf("%s: completionSummary() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestFilterTasks(t *testing.T) {
 	tasks := []Task{
 		{Description: "a", Status: StatusDone},
@@ -140,3 +195,65 @@ func TestPriorityOptions(t *testing.T) {
 		t.Fatalf("expected 4 options, got %d", len(opts))
 	}
 }
+
+func TestDueDisplay(t *testing.T) {
+	today := "2025-06-10"
+	tests := []struct {
+		task Task
+		want string
+	}{
+		{Task{}, ""},
+		{Task{DueDate: "2025-06-12"}, "12/06"},
+		{Task{DueDate: "2025-06-10"}, "! today"},
+		{Task{DueDate: "2025-06-01"}, "! overdue"},
+		{Task{DueDate: "2025-06-01", Status: StatusDone}, "01/06"},
+	}
+	for _, tt := range tests {
+		if got := tt.task.DueDisplay(today); got != tt.want {
+			t.Errorf("DueDisplay(%q, status %d) = %q, want %q", tt.task.DueDate, tt.task.Status, got, tt.want)
+		}
+	}
+}
+
+func TestIsOverdueAndDueOn(t *testing.T) {
+	today := "2025-06-10"
+	overdue := Task{DueDate: "2025-06-09"}
+	if !overdue.IsOverdue(today) || overdue.IsDueOn(today) {
+		t.Error("task due yesterday should be overdue and not due today")
+	}
+
+	dueToday := Task{DueDate: today, Status: StatusInProgress}
+	if dueToday.IsOverdue(today) || !dueToday.IsDueOn(today) {
+		t.Error("task due today should be due today and not overdue")
+	}
+
+	noDue := Task{}
+	if noDue.IsOverdue(today) || noDue.IsDueOn(today) {
+		t.Error("task without a due date should never be overdue or due")
+	}
+}
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"30m", 30 * time.Minute, true},
+		{"2h", 2 * time.Hour, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"1.5h", 90 * time.Minute, true},
+		{"1d", 8 * time.Hour, true},
+		{"45", 45 * time.Minute, true},
+		{"2 H", 2 * time.Hour, true},
+		{"", 0, false},
+		{"soon", 0, false},
+		{"3x", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseEstimate(tt.in)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseEstimate(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the set of colours used by the TUI and styled reports. Colours are usually
+// lipgloss.AdaptiveColor so they read well on both light and dark terminals.
+type Theme struct {
+	PriorityA lipgloss.TerminalColor
+	PriorityB lipgloss.TerminalColor
+	PriorityC lipgloss.TerminalColor
+	PriorityD lipgloss.TerminalColor
+	Accent    lipgloss.TerminalColor // title and selected row
+	Selected  lipgloss.TerminalColor // text on the selected row
+	Success   lipgloss.TerminalColor // status messages and stats bars
+	Muted     lipgloss.TerminalColor // help text and empty bar segments
+	Border    lipgloss.TerminalColor
+	Reverse   bool // highlight the selected row in reverse video instead of with Accent
+}
+
+// ThemeColors are the custom colours a config file may set under [colors]. Each is a hex
+// colour ("#ef4444") or an ANSI colour number ("9"); anything left blank keeps the theme's colour.
+type ThemeColors struct {
+	PriorityA string `toml:"priority_a,omitempty"`
+	PriorityB string `toml:"priority_b,omitempty"`
+	PriorityC string `toml:"priority_c,omitempty"`
+	PriorityD string `toml:"priority_d,omitempty"`
+	Accent    string `toml:"accent,omitempty"`
+	Success   string `toml:"success,omitempty"`
+	Muted     string `toml:"muted,omitempty"`
+	Border    string `toml:"border,omitempty"`
+}
+
+// themeNames lists the themes the theme setting accepts, in the order they're documented.
+var themeNames = []string{"default", "high-contrast", "colour-blind", "monochrome"}
+
+var themes = map[string]Theme{
+	"default": {
+		PriorityA: lipgloss.Color("#ef4444"), // red
+		PriorityB: lipgloss.Color("#f97316"), // orange
+		PriorityC: lipgloss.Color("#0ea5e9"), // sky
+		PriorityD: lipgloss.Color("#a1a1aa"), // zinc
+		Accent:    lipgloss.AdaptiveColor{Light: "#6d28d9", Dark: "#7c3aed"},
+		Selected:  lipgloss.Color("#fff"),
+		Success:   lipgloss.AdaptiveColor{Light: "#15803d", Dark: "#22c55e"},
+		Muted:     lipgloss.AdaptiveColor{Light: "#999", Dark: "#666"},
+		Border:    lipgloss.AdaptiveColor{Light: "#bbb", Dark: "#555"},
+	},
+	"high-contrast": {
+		PriorityA: lipgloss.AdaptiveColor{Light: "#b00000", Dark: "#ff5555"},
+		PriorityB: lipgloss.AdaptiveColor{Light: "#7a5c00", Dark: "#ffff55"},
+		PriorityC: lipgloss.AdaptiveColor{Light: "#0000c0", Dark: "#55ffff"},
+		PriorityD: lipgloss.AdaptiveColor{Light: "#000000", Dark: "#ffffff"},
+		Accent:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#ffffff"},
+		Selected:  lipgloss.AdaptiveColor{Light: "#ffffff", Dark: "#000000"},
+		Success:   lipgloss.AdaptiveColor{Light: "#005f00", Dark: "#55ff55"},
+		Muted:     lipgloss.AdaptiveColor{Light: "#444444", Dark: "#bbbbbb"},
+		Border:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#ffffff"},
+	},
+	// Okabe–Ito palette: the priorities differ in hue and lightness, so red/green and
+	// red/orange confusion doesn't matter.
+	"colour-blind": {
+		PriorityA: lipgloss.Color("#d55e00"),                                 // vermilion
+		PriorityB: lipgloss.AdaptiveColor{Light: "#0072b2", Dark: "#56b4e9"}, // blue
+		PriorityC: lipgloss.AdaptiveColor{Light: "#8a7e00", Dark: "#f0e442"}, // yellow
+		PriorityD: lipgloss.AdaptiveColor{Light: "#888888", Dark: "#aaaaaa"}, // grey
+		Accent:    lipgloss.Color("#0072b2"),
+		Selected:  lipgloss.Color("#fff"),
+		Success:   lipgloss.AdaptiveColor{Light: "#007a5a", Dark: "#009e73"}, // bluish green
+		Muted:     lipgloss.AdaptiveColor{Light: "#999", Dark: "#666"},
+		Border:    lipgloss.AdaptiveColor{Light: "#bbb", Dark: "#555"},
+	},
+	"monochrome": {
+		PriorityA: lipgloss.NoColor{},
+		PriorityB: lipgloss.NoColor{},
+		PriorityC: lipgloss.NoColor{},
+		PriorityD: lipgloss.NoColor{},
+		Accent:    lipgloss.NoColor{},
+		Selected:  lipgloss.NoColor{},
+		Success:   lipgloss.NoColor{},
+		Muted:     lipgloss.NoColor{},
+		Border:    lipgloss.NoColor{},
+		Reverse:   true,
+	},
+}
+
+// theme is the active theme; setupConfig replaces it with the configured one.
+var theme Theme
+
+func init() {
+	applyTheme(themes["default"])
+}
+
+// configuredTheme is the theme named in the config with any custom [colors] applied.
+// NO_COLOR (https://no-color.org) always wins and gives the monochrome theme.
+func configuredTheme(cfg Config) Theme {
+	if os.Getenv("NO_COLOR") != "" {
+		return themes["monochrome"]
+	}
+
+	t := themes[cfg.Theme]
+	c := cfg.Colors
+	for _, o := range []struct {
+		value  string
+		target *lipgloss.TerminalColor
+	}{
+		{c.PriorityA, &t.PriorityA},
+		{c.PriorityB, &t.PriorityB},
+		{c.PriorityC, &t.PriorityC},
+		{c.PriorityD, &t.PriorityD},
+		{c.Accent, &t.Accent},
+		{c.Success, &t.Success},
+		{c.Muted, &t.Muted},
+		{c.Border, &t.Border},
+	} {
+		if o.value != "" {
+			*o.target = lipgloss.Color(o.value)
+		}
+	}
+	if c.Accent != "" {
+		t.Reverse = false
+	}
+	return t
+}
+
+// applyTheme makes t the active theme and rebuilds the shared styles from it.
+func applyTheme(t Theme) {
+	theme = t
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(t.Accent).Padding(0, 1)
+	infoStyle = lipgloss.NewStyle().Foreground(t.Success)
+	statusStyle = lipgloss.NewStyle().Foreground(t.Success).Italic(true)
+	helpStyle = lipgloss.NewStyle().Foreground(t.Muted)
+}
+
+// selectedStyle highlights the table's selected row.
+func (t Theme) selectedStyle(base lipgloss.Style) lipgloss.Style {
+	if t.Reverse {
+		return base.Reverse(true).Bold(true)
+	}
+	return base.Foreground(t.Selected).Background(t.Accent).Bold(true)
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validate checks that every custom colour is a hex colour or an ANSI colour number.
+func (c ThemeColors) validate() error {
+	for _, o := range []struct{ name, value string }{
+		{"priority_a", c.PriorityA},
+		{"priority_b", c.PriorityB},
+		{"priority_c", c.PriorityC},
+		{"priority_d", c.PriorityD},
+		{"accent", c.Accent},
+		{"success", c.Success},
+		{"muted", c.Muted},
+		{"border", c.Border},
+	} {
+		if o.value == "" || hexColor.MatchString(o.value) {
+			continue
+		}
+		if n, err := strconv.Atoi(o.value); err == nil && n >= 0 && n <= 255 {
+			continue
+		}
+		return fmt.Errorf("colors.%s must be a hex colour like \"#ef4444\" or an ANSI number 0-255, got %q", o.name, o.value)
+	}
+	return nil
+}
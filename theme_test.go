@@ -0,0 +1,116 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// useTheme makes t the active theme for the duration of a test.
+func useTheme(t *testing.T, th Theme) {
+	t.Helper()
+	old := theme
+	applyTheme(th)
+	t.Cleanup(func() { applyTheme(old) })
+}
+
+func TestThemesComplete(t *testing.T) {
+	for _, name := range themeNames {
+		th, ok := themes[name]
+		if !ok {
+			t.Errorf("theme %q is listed but not defined", name)
+			continue
+		}
+		for _, c := range []lipgloss.TerminalColor{th.PriorityA, th.PriorityB, th.PriorityC, th.PriorityD, th.Accent, th.Selected, th.Success, th.Muted, th.Border} {
+			if c == nil {
+				t.Errorf("theme %q has an unset colour", name)
+			}
+		}
+	}
+	if len(themes) != len(themeNames) {
+		t.Errorf("themeNames lists %d themes, %d defined", len(themeNames), len(themes))
+	}
+}
+
+func TestPriorityColorFollowsTheme(t *testing.T) {
+	useTheme(t, themes["colour-blind"])
+
+	if got := PriorityA.Color(); got != lipgloss.Color("#d55e00") {
+		t.Errorf("PriorityA.Color() = %v, want colour-blind vermilion", got)
+	}
+}
+
+func TestConfiguredTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	cfg := defaultConfig()
+	cfg.Theme = "monochrome"
+	cfg.Colors = ThemeColors{PriorityA: "#ff00ff", Accent: "5"}
+	th := configuredTheme(cfg)
+
+	if th.PriorityA != lipgloss.Color("#ff00ff") {
+		t.Errorf("PriorityA = %v, want custom colour", th.PriorityA)
+	}
+	if th.Accent != lipgloss.Color("5") {
+		t.Errorf("Accent = %v, want custom colour", th.Accent)
+	}
+	if th.PriorityB != (lipgloss.NoColor{}) {
+		t.Errorf("PriorityB = %v, want the theme's colour", th.PriorityB)
+	}
+	if th.Reverse {
+		t.Error("a custom accent should replace reverse-video selection")
+	}
+}
+
+func TestConfiguredThemeNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	cfg := defaultConfig()
+	cfg.Colors = ThemeColors{PriorityA: "#ff00ff"}
+	th := configuredTheme(cfg)
+
+	if th.PriorityA != (lipgloss.NoColor{}) || !th.Reverse {
+		t.Errorf("expected the monochrome theme under NO_COLOR, got %+v", th)
+	}
+}
+
+func TestLoadConfigColors(t *testing.T) {
+	cfg, err := loadConfig(writeConfig(t, `
+theme = "colour-blind"
+
+[colors]
+priority_a = "#f0f"
+muted = "244"
+`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != "colour-blind" || cfg.Colors.PriorityA != "#f0f" || cfg.Colors.Muted != "244" {
+		t.Errorf("got %+v", cfg)
+	}
+
+	tests := []string{`priority_b = "orange"`, `accent = "256"`, `border = "#12345"`, `sparkle = "#fff"`}
+	for _, line := range tests {
+		if _, err := loadConfig(writeConfig(t, "[colors]\n"+line), true); err == nil {
+			t.Errorf("expected an error for %s", line)
+		}
+	}
+}
+
+func TestRunConfigShowOmitsUnsetColors(t *testing.T) {
+	useDBFlag(t, filepath.Join(t.TempDir(), "tasks.db"))
+	out := captureOutput(t, func() error { return runConfig(nil, []string{"show"}) })
+	if strings.Contains(out, "[colors]") {
+		t.Errorf("expected no [colors] table without custom colours:\n%s", out)
+	}
+
+	cfg := defaultConfig()
+	cfg.Colors.PriorityA = "#f0f"
+	useConfig(t, cfg)
+	out = captureOutput(t, func() error { return runConfig(nil, []string{"show"}) })
+	if !strings.Contains(out, "[colors]") || !strings.Contains(out, `priority_a = "#f0f"`) {
+		t.Errorf("expected custom colours in output:\n%s", out)
+	}
+}
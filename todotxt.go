@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// todo.txt (https://github.com/todotxt/todo.txt) export and import, one task per line:
+//
+//	x 2025-06-02 2025-06-01 Renew TLS cert +ops @work t:2025-06-02 due:2025-06-05 est:1h pri:A uid:…
+//
+// Priority, completion, creation date, +projects and @contexts are standard. Everything else
+// uses key:value extensions: t: (threshold, used here for the planned day), due:, est:, status:
+// for the states todo.txt has no marker for, pri: to keep a finished task's priority, pomo:,
+// note: (URL-escaped so it stays on one line) and uid:. #tags in a description are written as
+// +projects and read back as #tags. A description that wouldn't read back as it is (a word
+// like status:page or +word, or a leading date) is written URL-escaped in desc: instead.
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// todoTxtStatus names the statuses written with status:.
+var todoTxtStatus = map[Status]string{
+	StatusInProgress: "wip",
+	StatusBlocked:    "blocked",
+	StatusDelegated:  "delegated",
+	StatusCancelled:  "cancelled",
+}
+
+// writeTodoTxt writes tasks in todo.txt format.
+func writeTodoTxt(w io.Writer, tasks []Task) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range tasks {
+		fmt.Fprintln(bw, todoTxtLine(t))
+	}
+	return bw.Flush()
+}
+
+func todoTxtLine(t Task) string {
+	var words []string
+	for _, word := range strings.Fields(t.Description) {
+		if len(word) > 1 && word[0] == '#' {
+			word = "+" + word[1:]
+		}
+		words = append(words, word)
+	}
+	line := todoTxtLineWith(t, words)
+	if back, err := parseTodoTxtLine(line); err != nil || back.Description != t.Description {
+		line = todoTxtLineWith(t, []string{"desc:" + url.PathEscape(t.Description)})
+	}
+	return line
+}
+
+// todoTxtLineWith writes t's line with words standing in for the description.
+func todoTxtLineWith(t Task, words []string) string {
+	var parts []string
+	created := t.Date
+	if !t.CreatedAt.IsZero() {
+		created = t.CreatedAt.Local().Format("2006-01-02")
+	}
+
+	if t.Status == StatusDone {
+		parts = append(parts, "x")
+		if !t.CompletedAt.IsZero() {
+			// A completion date has to be followed by the creation date
+			parts = append(parts, t.CompletedAt.Local().Format("2006-01-02"), created)
+		}
+	} else {
+		parts = append(parts, "("+string(t.Priority)+")", created)
+	}
+
+	parts = append(parts, words...)
+
+	if t.Context != "" {
+		parts = append(parts, "@"+url.PathEscape(t.Context))
+	}
+	parts = append(parts, "t:"+t.Date)
+	if t.HasDueDate() {
+		parts = append(parts, "due:"+t.DueDate)
+	}
+	if t.TimeEstimate != "" {
+		parts = append(parts, "est:"+url.PathEscape(t.TimeEstimate))
+	}
+	if s, ok := todoTxtStatus[t.Status]; ok {
+		parts = append(parts, "status:"+s)
+	}
+	if t.Status == StatusDone {
+		parts = append(parts, "pri:"+string(t.Priority))
+	}
+	if t.Pomodoros > 0 {
+		parts = append(parts, "pomo:"+strconv.Itoa(t.Pomodoros))
+	}
+	if t.Notes != "" {
+		parts = append(parts, "note:"+url.PathEscape(t.Notes))
+	}
+	if t.UID != "" {
+		parts = append(parts, "uid:"+t.UID)
+	}
+	return strings.Join(parts, " ")
+}
+
+// readTodoTxt parses todo.txt lines into tasks. Blank lines are skipped. A task without a t:
+// date is planned on its due date, then its creation date, then today.
+func readTodoTxt(r io.Reader) ([]Task, error) {
+	var tasks []Task
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		t, err := parseTodoTxtLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, scanner.Err()
+}
+
+func parseTodoTxtLine(line string) (Task, error) {
+	t := Task{Priority: config.DefaultPriority}
+	words := strings.Fields(line)
+
+	// Leading markers: "x [completed [created]]" or "(A) [created]"
+	var created string
+	if words[0] == "x" {
+		t.Status = StatusDone
+		words = words[1:]
+		if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+			t.CompletedAt = todoTxtTime(words[0])
+			words = words[1:]
+		}
+	} else if m := todoTxtPriority.FindStringSubmatch(words[0]); m != nil {
+		t.Priority = todoTxtPriorityLetter(m[1])
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+		created = words[0]
+		t.CreatedAt = todoTxtTime(created)
+		words = words[1:]
+	}
+
+	// The last @context is the task's context; any others are part of the description
+	contextAt := -1
+	for i, word := range words {
+		if len(word) > 1 && word[0] == '@' {
+			contextAt = i
+		}
+	}
+
+	var desc []string
+	for i, word := range words {
+		if i == contextAt {
+			context, err := url.PathUnescape(word[1:])
+			if err != nil {
+				return Task{}, fmt.Errorf("context %q: %w", word, err)
+			}
+			t.Context = context
+			continue
+		}
+		if key, value, ok := strings.Cut(word, ":"); ok && value != "" {
+			handled, err := setTodoTxtField(&t, key, value)
+			if err != nil {
+				return Task{}, err
+			}
+			if handled {
+				continue
+			}
+		}
+		if len(word) > 1 && word[0] == '+' && unicode.IsLetter([]rune(word)[1]) {
+			word = "#" + word[1:]
+		}
+		desc = append(desc, word)
+	}
+	// Words beside a desc: are kept after it
+	t.Description = strings.TrimSpace(t.Description + " " + strings.Join(desc, " "))
+
+	switch {
+	case t.Date != "":
+	case t.DueDate != "":
+		t.Date = t.DueDate
+	case created != "":
+		t.Date = created
+	default:
+		t.Date = time.Now().Format("2006-01-02")
+	}
+	return t, nil
+}
+
+// setTodoTxtField applies a key:value extension. Unknown keys aren't handled and stay in the
+// description, so nothing from other todo.txt tools is lost.
+func setTodoTxtField(t *Task, key, value string) (handled bool, err error) {
+	switch key {
+	case "t", "due":
+		if !todoTxtDate.MatchString(value) {
+			return false, fmt.Errorf("%s: expected yyyy-mm-dd, got %q", key, value)
+		}
+		if key == "t" {
+			t.Date = value
+		} else {
+			t.DueDate = value
+		}
+	case "est":
+		t.TimeEstimate, err = url.PathUnescape(value)
+	case "status":
+		found := false
+		for s, name := range todoTxtStatus {
+			if strings.EqualFold(value, name) {
+				t.Status, found = s, true
+			}
+		}
+		if !found {
+			return false, fmt.Errorf("status: expected wip, blocked, delegated or cancelled, got %q", value)
+		}
+	case "pri":
+		if len(value) != 1 || value[0] < 'A' || value[0] > 'Z' {
+			return false, fmt.Errorf("pri: expected a letter, got %q", value)
+		}
+		t.Priority = todoTxtPriorityLetter(value)
+	case "pomo":
+		if t.Pomodoros, err = strconv.Atoi(value); err != nil {
+			return false, fmt.Errorf("pomo: expected a number, got %q", value)
+		}
+	case "note":
+		t.Notes, err = url.PathUnescape(value)
+	case "desc":
+		t.Description, err = url.PathUnescape(value)
+	case "uid":
+		t.UID = value
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", key, err)
+	}
+	return true, nil
+}
+
+// todoTxtPriorityLetter maps todo.txt's A–Z onto A–D; anything below D is D.
+func todoTxtPriorityLetter(letter string) Priority {
+	if letter > "D" {
+		return PriorityD
+	}
+	return Priority(letter)
+}
+
+// todoTxtTime reads a todo.txt date as local midnight.
+func todoTxtTime(date string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+	return t
+}
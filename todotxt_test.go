@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)
+	completed := time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local)
+	tasks := []Task{
+		{UID: "a", Date: "2025-06-02", Description: "Renew TLS cert #ops", Priority: PriorityA, TimeEstimate: "1h", Status: StatusDone,
+			Context: "work", Notes: "Line one\nLine two: 100%", Pomodoros: 2, CreatedAt: created, CompletedAt: completed},
+		{UID: "b", Date: "2025-06-02", Description: "Chase vendor", Priority: PriorityB, Status: StatusDelegated, Context: "work", DueDate: "2025-06-06", CreatedAt: created},
+		{UID: "c", Date: "2025-06-03", Description: "Ask @alice about DNS", Priority: PriorityC, Status: StatusInProgress, Context: "side project", CreatedAt: created},
+		{UID: "d", Date: "2025-06-03", Description: "Waiting on http://example.com", Priority: PriorityD, Status: StatusBlocked, Context: "home", CreatedAt: created},
+		{UID: "e", Date: "2025-06-03", Description: "Not needed", Priority: PriorityB, Status: StatusCancelled, Context: "home", CreatedAt: created},
+		{UID: "f", Date: "2025-06-04", Description: "Plain", Priority: PriorityB, Status: StatusTodo, Context: "home", CreatedAt: created},
+		// Descriptions that would read back as something else
+		{UID: "g", Date: "2025-06-04", Description: "Check status:page", Priority: PriorityA, Status: StatusDone, Context: "work", CreatedAt: created, CompletedAt: completed},
+		{UID: "h", Date: "2025-06-04", Description: "Ping due:soon", Priority: PriorityB, Status: StatusTodo, Context: "work", CreatedAt: created},
+		{UID: "i", Date: "2025-06-04", Description: "Review +ops rota", Priority: PriorityB, Status: StatusTodo, Context: "work", CreatedAt: created},
+		{UID: "j", Date: "2025-06-04", Description: "2025-05-30 outage review", Priority: PriorityB, Status: StatusDone, Context: "work"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTodoTxt(&buf, tasks); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"x 2025-06-02 2025-06-01 Renew TLS cert +ops @work t:2025-06-02 est:1h pri:A pomo:2 note:Line%20one%0ALine%20two:%20100%25 uid:a\n",
+		"(B) 2025-06-01 Chase vendor @work t:2025-06-02 due:2025-06-06 status:delegated uid:b\n",
+		"@side%20project", "status:wip", "status:blocked", "status:cancelled",
+		"(B) 2025-06-01 Plain @home t:2025-06-04 uid:f\n",
+		"(B) 2025-06-01 desc:Review%20+ops%20rota @work t:2025-06-04 uid:i\n",
+		"x desc:2025-05-30%20outage%20review @work t:2025-06-04 pri:B uid:j\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	got, err := readTodoTxt(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, tasks) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got, tasks)
+	}
+}
+
+func TestReadTodoTxtFromOtherApps(t *testing.T) {
+	input := strings.Join([]string{
+		"(A) Thank Mom for the meatballs @phone",
+		"",
+		"(F) 2025-06-01 Post signs around the neighborhood +GarageSale @errands @home due:2025-06-10 rec:+1w",
+		"x 2025-06-03 2025-06-01 Call plumber pri:C",
+		"Buy milk",
+	}, "\n")
+
+	tasks, err := readTodoTxt(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("expected 4 tasks, got %+v", tasks)
+	}
+	today := time.Now().Format("2006-01-02")
+	if got := tasks[0]; got.Description != "Thank Mom for the meatballs" || got.Priority != PriorityA || got.Context != "phone" || got.Date != today {
+		t.Errorf("first task = %+v", got)
+	}
+	if got := tasks[1]; got.Description != "Post signs around the neighborhood #GarageSale @errands rec:+1w" || got.Priority != PriorityD ||
+		got.Context != "home" || got.DueDate != "2025-06-10" || got.Date != "2025-06-10" {
+		t.Errorf("second task = %+v", got)
+	}
+	if got := tasks[2]; got.Status != StatusDone || got.Priority != PriorityC || got.Date != "2025-06-01" || got.CompletedAt.Format("2006-01-02") != "2025-06-03" {
+		t.Errorf("third task = %+v", got)
+	}
+	if got := tasks[3]; got.Description != "Buy milk" || got.Priority != config.DefaultPriority || got.Context != "" || got.Status != StatusTodo {
+		t.Errorf("fourth task = %+v", got)
+	}
+
+	for _, bad := range []string{"Call due:friday", "Call t:2025/06/01", "Call status:someday", "Call pri:high", "Call pomo:lots", "Call note:%zz"} {
+		if _, err := readTodoTxt(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestExportImportTodoTxt(t *testing.T) {
+	src := newTestStore(t)
+	src.AddTask("2025-06-01", "Patch web servers #ops", PriorityA, "2h", "default")
+	src.AddTask("2025-06-02", "Order toner", PriorityC, "5m", "default")
+
+	output := captureOutput(t, func() error { return runExport(src, []string{"--format", "todotxt"}) })
+	if strings.Count(output, "\n") != 2 || !strings.Contains(output, "(A) ") || !strings.Contains(output, "+ops @default") {
+		t.Fatalf("unexpected todo.txt export:\n%s", output)
+	}
+
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := newTestStore(t)
+	msg := captureOutput(t, func() error { return runImport(dst, []string{path}) })
+	if !strings.Contains(msg, "Imported 2 task(s)") {
+		t.Errorf("unexpected import message: %q", msg)
+	}
+	msg = captureOutput(t, func() error { return runImport(dst, []string{path, "--format", "todotxt"}) })
+	if !strings.Contains(msg, "skipped 2 already imported") {
+		t.Errorf("expected a second import to add nothing, got %q", msg)
+	}
+
+	tasks, _ := dst.GetTasksForDate("2025-06-01", "default")
+	if len(tasks) != 1 || tasks[0].Description != "Patch web servers #ops" || tasks[0].TimeEstimate != "2h" || tasks[0].Priority != PriorityA {
+		t.Errorf("expected the first task back intact, got %+v", tasks)
+	}
+}
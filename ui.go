@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,12 +13,12 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Styles
+// Styles, built from the active theme by applyTheme
 var (
-	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7c3aed")).Padding(0, 1)
-	infoStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
-	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e")).Italic(true)
-	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#666"))
+	titleStyle  lipgloss.Style
+	infoStyle   lipgloss.Style
+	statusStyle lipgloss.Style
+	helpStyle   lipgloss.Style
 )
 
 // App modes
@@ -29,51 +30,112 @@ const (
 	modeEdit
 	modeConfirmDelete
 	modeConfirmCarry
-	modeViewDate
+	modeCalendar
 	modeFilter
+	modeHistory
+	modeStats
+	modeSwitchContext
+	modeHelp
+	modeSearch
+	modeFocus
+	modeInbox
+	modeInboxSchedule
+	modeInboxDelegate
 )
 
 type model struct {
-	store   *Store
-	date    string
-	context string
-	tasks   []Task
-	table   table.Model
-	mode    mode
-	form    *huh.Form
-	status  string
-	width   int
-	height  int
+	store       *Store
+	date        string
+	context     string
+	allContexts bool // merged view of every context; new tasks still go to context
+	tasks       []Task
+	table       table.Model
+	mode        mode
+	form        *huh.Form
+	status      string
+	width       int
+	height      int
+	keys        keyMap
+	help        help.Model
 
 	// Form field bindings (pointer receiver keeps addresses stable)
 	formDesc     string
 	formPriority Priority
 	formEstimate string
-	formDate     string
+	formDue      string
+	formNotes    string
 	formConfirm  bool
+	formContext  string
+	formDate     string
+	formChoice   string
 
 	// Filter
 	filterText    string
+	filterQuery   query
+	filterErr     string // why filterText doesn't parse; the previous results stay shown
 	filteredTasks []Task
 
 	// Context for current action
 	editTaskID          int64
+	editTaskContext     string
 	carryCandidates     []Task
 	latestDateWithTasks string
+
+	// History view
+	historyTask   Task
+	historyEvents []TaskEvent
+
+	// Stats view
+	stats Stats
+
+	// Global search
+	searchText    string
+	searchResults []Task
+	searchTable   table.Model
+
+	// Calendar view
+	calendarDate string // selected day, yyyy-mm-dd
+	calendarDays map[string]daySummary
+
+	// Focus (pomodoro) mode
+	focusTask    Task
+	focusPhase   focusPhase
+	focusStarted time.Time // when the running pomodoro or break began
+	focusEnd     time.Time // when it finishes
+	focusPaused  bool
+	focusLeft    time.Duration // time left when paused
+	focusSession int           // current tick chain; see focusTickMsg
+	focusCount   int           // pomodoros finished since focus mode was entered
+
+	// Inbox processing
+	inboxItems   []InboxItem    // left to process, current one first
+	inboxBacklog bool           // processing the backlog rather than the inbox
+	inboxSkipped map[int64]bool // left for later this time round
+	inboxCount   int            // items waiting in the inbox, for the summary line
 }
 
-func newModel(store *Store, date, context string) *model {
+// allContextsChoice is the context switcher's value for the merged view.
+const allContextsChoice = "\x00all"
+
+func newModel(store *Store, date, context string, allContexts bool) *model {
 	m := &model{
-		store:   store,
-		date:    date,
-		context: context,
-		width:   80,
+		store:       store,
+		date:        date,
+		context:     context,
+		allContexts: allContexts,
+		width:       80,
+		keys:        newKeyMap(config.Keymap, config.Keys),
+		help:        newHelp(),
 	}
 	m.refreshTasks()
+	m.processInbox(false)
 	return m
 }
 
 func (m *model) Init() tea.Cmd {
+	if m.mode == modeInbox {
+		return m.form.Init()
+	}
 	return nil
 }
 
@@ -88,6 +150,8 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+	case focusTickMsg:
+		return m.focusTicked(msg)
 	}
 
 	switch m.mode {
@@ -95,17 +159,35 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateTable(msg)
 	case modeFilter:
 		return m.updateFilter(msg)
+	case modeHistory:
+		return m.updateHistory(msg)
+	case modeStats:
+		return m.updateStats(msg)
+	case modeHelp:
+		return m.updateHelp(msg)
+	case modeSearch:
+		return m.updateSearch(msg)
+	case modeCalendar:
+		return m.updateCalendar(msg)
+	case modeFocus:
+		return m.updateFocus(msg)
 	default:
 		return m.updateForm(msg)
 	}
 }
 
 func (m *model) View() string {
+	if m.mode == modeFocus {
+		return m.focusView()
+	}
+
 	var s strings.Builder
 
 	s.WriteString("\n")
 	heading := formatHeading(m.date)
-	if m.context != "default" {
+	if m.allContexts {
+		heading += " · all contexts"
+	} else if m.context != config.DefaultContext {
 		heading += " · " + m.context
 	}
 	s.WriteString(titleStyle.Render(heading))
@@ -119,27 +201,35 @@ func (m *model) View() string {
 			if m.latestDateWithTasks != "" {
 				lt, _ := time.Parse("2006-01-02", m.latestDateWithTasks)
 				s.WriteString("\n")
-				s.WriteString(helpStyle.Render(fmt.Sprintf("  Press i to import tasks from %s", lt.Format("Monday 2 January 2006"))))
+				s.WriteString(helpStyle.Render(fmt.Sprintf("  Press %s to import tasks from %s", m.keys.Import.Help().Key, lt.Format("Monday 2 January 2006"))))
 				s.WriteString("\n")
 			}
 		} else {
 			s.WriteString(m.table.View())
 			s.WriteString("\n\n")
-			completed := len(filterTasks(m.tasks, func(t Task) bool { return t.Status == StatusDone }))
-			inProgress := len(filterTasks(m.tasks, func(t Task) bool { return t.Status == StatusInProgress }))
-			summary := fmt.Sprintf("  %d/%d tasks completed", completed, len(m.tasks))
-			if inProgress > 0 {
-				summary += fmt.Sprintf(", %d in progress", inProgress)
+			summary := "  " + completionSummary(m.tasks)
+			today := time.Now().Format("2006-01-02")
+			if overdue := len(filterTasks(m.tasks, func(t Task) bool { return t.IsOverdue(today) })); overdue > 0 {
+				summary += fmt.Sprintf(", %d overdue", overdue)
+			}
+			if dueToday := len(filterTasks(m.tasks, func(t Task) bool { return t.IsDueOn(today) })); dueToday > 0 {
+				summary += fmt.Sprintf(", %d due today", dueToday)
 			}
 			if m.filteredTasks != nil {
 				summary += fmt.Sprintf(" (showing %d)", len(m.filteredTasks))
 			}
+			if m.inboxCount > 0 {
+				summary += fmt.Sprintf(" · %d in inbox", m.inboxCount)
+			}
 			s.WriteString(infoStyle.Render(summary))
 		}
 
 		if m.mode == modeFilter {
 			s.WriteString("\n\n")
 			s.WriteString(statusStyle.Render(fmt.Sprintf("  / %s▌", m.filterText)))
+			if m.filterErr != "" {
+				s.WriteString(helpStyle.Render("  " + m.filterErr))
+			}
 		} else if m.status != "" {
 			s.WriteString("\n\n")
 			s.WriteString(statusStyle.Render("  " + m.status))
@@ -147,27 +237,47 @@ func (m *model) View() string {
 
 		s.WriteString("\n\n")
 		if m.mode == modeFilter {
-			s.WriteString(helpStyle.Render("  type to filter · enter accept · esc clear"))
+			s.WriteString(helpStyle.Render("  words fuzzy-match · p:A status:wip est:>1h carried:yes tag:x · -term negates · enter accept · esc clear"))
 		} else if len(m.tasks) == 0 {
-			help := "  a add · v view day · q quit"
-			if m.latestDateWithTasks != "" {
-				help = "  a add · i import · v view day · q quit"
-			}
-			s.WriteString(helpStyle.Render(help))
+			s.WriteString("  " + m.help.ShortHelpView(m.keys.emptyDayHelp(m.latestDateWithTasks != "")))
 		} else {
-			s.WriteString(helpStyle.Render("  a add · s start · d done · e/↵ edit · x delete · c carry · / search · 1-9 jump · v view · q quit"))
+			s.WriteString("  " + m.help.ShortHelpView(m.keys.ShortHelp()))
 		}
 		s.WriteString("\n")
 
+	case modeHelp:
+		s.WriteString(m.helpView())
+
+	case modeSearch:
+		s.WriteString(m.searchView())
+
+	case modeCalendar:
+		s.WriteString(m.calendarView())
+
+	case modeHistory:
+		s.WriteString(m.historyView())
+
+	case modeStats:
+		for _, line := range strings.Split(strings.TrimRight(renderStats(m.stats, true), "\n"), "\n") {
+			s.WriteString("  " + line + "\n")
+		}
+		s.WriteString("\n")
+		s.WriteString(helpStyle.Render("  esc back"))
+		s.WriteString("\n")
+
 	case modeConfirmCarry:
 		toDate, _ := time.Parse("2006-01-02", tomorrow(m.date))
-		s.WriteString(fmt.Sprintf("  Carry %d task(s) to %s:\n\n", len(m.carryCandidates), toDate.Format("02/01/2006")))
+		s.WriteString(fmt.Sprintf("  Carry %d task(s) to %s:\n\n", len(m.carryCandidates), toDate.Format(config.dateLayout())))
 		for _, t := range m.carryCandidates {
 			s.WriteString(fmt.Sprintf("    - %s\n", t.Description))
 		}
 		s.WriteString("\n")
 		s.WriteString(m.form.View())
 
+	case modeInbox, modeInboxSchedule, modeInboxDelegate:
+		s.WriteString(m.inboxView())
+		s.WriteString(m.form.View())
+
 	default:
 		s.WriteString(m.form.View())
 	}
@@ -184,20 +294,43 @@ func (m *model) visibleTasks() []Task {
 	return m.tasks
 }
 
+// selectedTask returns the task under the cursor, or false when the visible list is empty
+// (including a filter that matches nothing).
+func (m *model) selectedTask() (Task, bool) {
+	tasks := m.visibleTasks()
+	cursor := m.table.Cursor()
+	if cursor < 0 || cursor >= len(tasks) {
+		return Task{}, false
+	}
+	return tasks[cursor], true
+}
+
+// applyFilter re-runs the filter query over the day's tasks. A query that doesn't parse (often
+// because it's half typed) leaves the previous results in place.
 func (m *model) applyFilter() {
 	if m.filterText == "" {
 		m.filteredTasks = nil
+		m.filterQuery = query{}
+		m.filterErr = ""
 	} else {
-		needle := strings.ToLower(m.filterText)
-		m.filteredTasks = filterTasks(m.tasks, func(t Task) bool {
-			return strings.Contains(strings.ToLower(t.Description), needle)
-		})
+		q, err := parseQuery(m.filterText)
+		if err != nil {
+			m.filterErr = err.Error()
+			return
+		}
+		m.filterQuery, m.filterErr = q, ""
+		m.filteredTasks = filterTasks(m.tasks, q.Match)
+		if m.filteredTasks == nil {
+			m.filteredTasks = []Task{} // nothing matched, as opposed to no filter
+		}
 	}
 	m.rebuildTable()
 }
 
 func (m *model) clearFilter() {
 	m.filterText = ""
+	m.filterQuery = query{}
+	m.filterErr = ""
 	m.filteredTasks = nil
 	m.rebuildTable()
 }
@@ -217,8 +350,8 @@ func (m *model) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.mode = modeTable
 		return m, nil
 	case "backspace":
-		if len(m.filterText) > 0 {
-			m.filterText = m.filterText[:len(m.filterText)-1]
+		if r := []rune(m.filterText); len(r) > 0 {
+			m.filterText = string(r[:len(r)-1])
 			m.applyFilter()
 		}
 		return m, nil
@@ -237,32 +370,52 @@ func (m *model) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *model) updateTable(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
 		m.status = "" // clear status on any keypress
-		switch keyMsg.String() {
-		case "q":
+		switch {
+		case key.Matches(keyMsg, m.keys.Quit):
 			return m, tea.Quit
-		case "a":
+		case key.Matches(keyMsg, m.keys.Help):
+			m.mode = modeHelp
+			return m, nil
+		case key.Matches(keyMsg, m.keys.Add):
 			return m.enterAddMode()
-		case "s":
-			return m.toggleInProgress()
-		case "d":
+		case key.Matches(keyMsg, m.keys.Start):
+			onMsg := fmt.Sprintf("Task marked as in progress. Press %s to focus on it.", m.keys.Focus.Help().Key)
+			return m.toggleStatus(StatusInProgress, onMsg, "Task no longer in progress.")
+		case key.Matches(keyMsg, m.keys.Focus):
+			return m.enterFocusMode()
+		case key.Matches(keyMsg, m.keys.Done):
 			return m.toggleDone()
-		case "e", "enter":
+		case key.Matches(keyMsg, m.keys.Blocked):
+			return m.toggleStatus(StatusBlocked, "Task marked as blocked.", "Task no longer blocked.")
+		case key.Matches(keyMsg, m.keys.Delegated):
+			return m.toggleStatus(StatusDelegated, "Task marked as delegated.", "Task no longer delegated.")
+		case key.Matches(keyMsg, m.keys.Cancel):
+			return m.toggleStatus(StatusCancelled, "Task cancelled.", "Task no longer cancelled.")
+		case key.Matches(keyMsg, m.keys.Edit):
 			return m.enterEditMode()
-		case "i":
+		case key.Matches(keyMsg, m.keys.Import):
 			return m.importTasks()
-		case "x":
+		case key.Matches(keyMsg, m.keys.Delete):
 			return m.enterDeleteMode()
-		case "c":
+		case key.Matches(keyMsg, m.keys.Carry):
 			return m.enterCarryMode()
-		case "v":
-			return m.enterViewDateMode()
-		case "/":
-			m.filterText = ""
-			m.filteredTasks = nil
+		case key.Matches(keyMsg, m.keys.ViewDate):
+			return m.enterCalendarMode()
+		case key.Matches(keyMsg, m.keys.History):
+			return m.enterHistoryMode()
+		case key.Matches(keyMsg, m.keys.Stats):
+			return m.enterStatsMode()
+		case key.Matches(keyMsg, m.keys.SwitchContext):
+			return m.enterSwitchContextMode()
+		case key.Matches(keyMsg, m.keys.GlobalSearch):
+			return m.enterSearchMode()
+		case key.Matches(keyMsg, m.keys.Inbox):
+			return m.enterInboxMode()
+		case key.Matches(keyMsg, m.keys.Search):
+			m.clearFilter()
 			m.mode = modeFilter
-			m.rebuildTable()
 			return m, nil
-		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		case key.Matches(keyMsg, m.keys.Jump):
 			num := int(keyMsg.Runes[0] - '0') // 1-based task number
 			// Find the original task by its 1-based index in m.tasks
 			if num <= len(m.tasks) {
@@ -284,13 +437,183 @@ func (m *model) updateTable(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// --- Global search ---
+
+func (m *model) enterSearchMode() (tea.Model, tea.Cmd) {
+	m.searchText = ""
+	m.searchResults = nil
+	m.rebuildSearchTable()
+	m.mode = modeSearch
+	return m, nil
+}
+
+// runSearch searches every date in the current context, or every context in the all-contexts view.
+func (m *model) runSearch() {
+	context := m.context
+	if m.allContexts {
+		context = ""
+	}
+	results, err := m.store.SearchTasks(m.searchText, context)
+	if err != nil {
+		m.status = "Error searching tasks."
+		results = nil
+	}
+	m.searchResults = results
+	m.rebuildSearchTable()
+}
+
+func (m *model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		m.mode = modeTable
+		return m, nil
+	case "enter":
+		if len(m.searchResults) > 0 {
+			return m.jumpToTask(m.searchResults[m.searchTable.Cursor()])
+		}
+		return m, nil
+	case "up", "down", "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.searchTable, cmd = m.searchTable.Update(msg)
+		return m, cmd
+	case "backspace":
+		if r := []rune(m.searchText); len(r) > 0 {
+			m.searchText = string(r[:len(r)-1])
+			m.runSearch()
+		}
+		return m, nil
+	default:
+		r := keyMsg.Runes
+		if len(r) > 0 && r[0] >= 32 {
+			m.searchText += string(r)
+			m.runSearch()
+		}
+		return m, nil
+	}
+}
+
+// jumpToTask shows the task's day with the task selected.
+func (m *model) jumpToTask(task Task) (tea.Model, tea.Cmd) {
+	m.date = task.Date
+	m.filterText = ""
+	m.refreshTasks()
+	for i, t := range m.visibleTasks() {
+		if t.ID == task.ID {
+			m.table.SetCursor(i)
+			break
+		}
+	}
+	m.mode = modeTable
+	return m, nil
+}
+
+func (m *model) rebuildSearchTable() {
+	taskWidth := m.width - (10 + 10 + 6 + 8)
+	if m.allContexts {
+		taskWidth -= contextColumnWidth + 2
+	}
+	taskWidth = max(20, min(taskWidth, 80))
+
+	cols := []table.Column{{Title: "Date", Width: 10}}
+	if m.allContexts {
+		cols = append(cols, table.Column{Title: "Context", Width: contextColumnWidth})
+	}
+	cols = append(cols,
+		table.Column{Title: "Task", Width: taskWidth},
+		table.Column{Title: "Priority", Width: 10},
+		table.Column{Title: "Status", Width: 6},
+	)
+
+	rows := make([]table.Row, len(m.searchResults))
+	for i, t := range m.searchResults {
+		date, _ := time.Parse("2006-01-02", t.Date)
+		row := table.Row{date.Format(config.dateLayout())}
+		if m.allContexts {
+			row = append(row, t.Context)
+		}
+		rows[i] = append(row, t.Description, string(t.Priority), t.Status.Symbol())
+	}
+
+	height := max(3, len(rows)+2)
+	if maxH := m.height - 10; maxH > 3 && height > maxH {
+		height = maxH
+	}
+	navigation := table.KeyMap{
+		LineUp:   key.NewBinding(key.WithKeys("up")),
+		LineDown: key.NewBinding(key.WithKeys("down")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup")),
+		PageDown: key.NewBinding(key.WithKeys("pgdown")),
+	}
+	m.searchTable = newTable(cols, rows, height, navigation)
+}
+
+func (m *model) searchView() string {
+	var s strings.Builder
+	scope := "all dates"
+	if m.allContexts {
+		scope += ", all contexts"
+	}
+	s.WriteString(statusStyle.Render(fmt.Sprintf("  Search %s: %s▌", scope, m.searchText)))
+	s.WriteString("\n\n")
+
+	switch {
+	case strings.TrimSpace(m.searchText) == "":
+		s.WriteString(helpStyle.Render("  Type to search task names and notes; \"quotes\" match a phrase."))
+		s.WriteString("\n")
+	case len(m.searchResults) == 0:
+		s.WriteString(infoStyle.Render("  No matching tasks."))
+		s.WriteString("\n")
+	default:
+		s.WriteString(m.searchTable.View())
+		s.WriteString("\n\n")
+		s.WriteString(infoStyle.Render(fmt.Sprintf("  %d result(s)", len(m.searchResults))))
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render("  ↑/↓ select · enter go to day · esc back"))
+	s.WriteString("\n")
+	return s.String()
+}
+
+// --- Help overlay ---
+
+func (m *model) updateHelp(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case keyMsg.String() == "esc", key.Matches(keyMsg, m.keys.Help), key.Matches(keyMsg, m.keys.Quit):
+			m.mode = modeTable
+		}
+	}
+	return m, nil
+}
+
+// helpView lists every binding in the active keymap.
+func (m *model) helpView() string {
+	var s strings.Builder
+	s.WriteString("  Keys\n\n")
+	m.help.Width = m.width - 2
+	for _, line := range strings.Split(m.help.FullHelpView(m.keys.FullHelp()), "\n") {
+		s.WriteString("  " + line + "\n")
+	}
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render("  esc close"))
+	s.WriteString("\n")
+	return s.String()
+}
+
 func (m *model) toggleDone() (tea.Model, tea.Cmd) {
-	if len(m.tasks) == 0 {
+	task, ok := m.selectedTask()
+	if !ok {
 		m.status = "No tasks."
 		return m, nil
 	}
 
-	task := m.visibleTasks()[m.table.Cursor()]
 	if task.Status == StatusDone {
 		m.store.MarkIncomplete(task.ID)
 		m.status = "Task marked as not done."
@@ -303,36 +626,186 @@ func (m *model) toggleDone() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *model) toggleInProgress() (tea.Model, tea.Cmd) {
-	if len(m.tasks) == 0 {
+// toggleStatus switches the selected task between the given status and todo.
+func (m *model) toggleStatus(status Status, onMsg, offMsg string) (tea.Model, tea.Cmd) {
+	task, ok := m.selectedTask()
+	if !ok {
 		m.status = "No tasks."
 		return m, nil
 	}
 
-	task := m.visibleTasks()[m.table.Cursor()]
-	if task.Status == StatusInProgress {
+	if task.Status == status {
 		m.store.MarkIncomplete(task.ID)
-		m.status = "Task no longer in progress."
+		m.status = offMsg
 	} else {
-		m.store.MarkInProgress(task.ID)
-		m.status = "Task marked as in progress."
+		m.store.SetStatus(task.ID, status)
+		m.status = onMsg
 	}
 
 	m.refreshTasks()
 	return m, nil
 }
 
+// --- History mode ---
+
+func (m *model) enterHistoryMode() (tea.Model, tea.Cmd) {
+	task, ok := m.selectedTask()
+	if !ok {
+		m.status = "No tasks."
+		return m, nil
+	}
+
+	events, err := m.store.GetTaskEvents(task.ID)
+	if err != nil {
+		m.status = "Error loading history."
+		return m, nil
+	}
+
+	m.historyTask = task
+	m.historyEvents = events
+	m.mode = modeHistory
+	return m, nil
+}
+
+func (m *model) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case keyMsg.String() == "esc", keyMsg.String() == "enter", key.Matches(keyMsg, m.keys.Quit, m.keys.History):
+			m.mode = modeTable
+		}
+	}
+	return m, nil
+}
+
+func (m *model) historyView() string {
+	var s strings.Builder
+
+	t := m.historyTask
+	s.WriteString(fmt.Sprintf("  History for '%s'\n\n", t.Description))
+	if !t.CreatedAt.IsZero() {
+		s.WriteString(fmt.Sprintf("  Created    %s\n", t.CreatedAt.Local().Format(config.dateLayout()+" 15:04")))
+	}
+	if !t.UpdatedAt.IsZero() {
+		s.WriteString(fmt.Sprintf("  Updated    %s\n", t.UpdatedAt.Local().Format(config.dateLayout()+" 15:04")))
+	}
+	if !t.CompletedAt.IsZero() {
+		s.WriteString(fmt.Sprintf("  Completed  %s\n", t.CompletedAt.Local().Format(config.dateLayout()+" 15:04")))
+	}
+	s.WriteString("\n")
+	if t.Notes != "" {
+		for _, line := range strings.Split(t.Notes, "\n") {
+			s.WriteString("  " + line + "\n")
+		}
+		s.WriteString("\n")
+	}
+
+	if len(m.historyEvents) == 0 {
+		s.WriteString(infoStyle.Render("  No recorded events for this task."))
+		s.WriteString("\n")
+	}
+	for _, e := range m.historyEvents {
+		line := fmt.Sprintf("  %s  %-10s", e.At.Local().Format(config.dateLayout()+" 15:04"), e.Event)
+		if e.Detail != "" {
+			line += "  " + e.Detail
+		}
+		s.WriteString(line + "\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render("  esc back"))
+	s.WriteString("\n")
+	return s.String()
+}
+
+// --- Stats mode ---
+
+// enterStatsMode shows completion stats for the 30 days up to the day being viewed.
+func (m *model) enterStatsMode() (tea.Model, tea.Cmd) {
+	t, _ := time.Parse("2006-01-02", m.date)
+	stats, err := m.store.GetStats(m.context, t.AddDate(0, 0, -30).Format("2006-01-02"))
+	if err != nil {
+		m.status = "Error loading stats."
+		return m, nil
+	}
+
+	m.stats = stats
+	m.mode = modeStats
+	return m, nil
+}
+
+func (m *model) updateStats(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case keyMsg.String() == "esc", keyMsg.String() == "enter", key.Matches(keyMsg, m.keys.Quit, m.keys.Stats):
+			m.mode = modeTable
+		}
+	}
+	return m, nil
+}
+
+// --- Context switching ---
+
+func (m *model) enterSwitchContextMode() (tea.Model, tea.Cmd) {
+	contexts, err := m.store.ListContexts()
+	if err != nil {
+		m.status = "Error loading contexts."
+		return m, nil
+	}
+
+	options := []huh.Option[string]{huh.NewOption("All contexts", allContextsChoice)}
+	seen := false
+	for _, c := range activeContexts(contexts) {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%d open)", c.Name, c.Open), c.Name))
+		seen = seen || c.Name == m.context
+	}
+	if !seen {
+		options = append(options, huh.NewOption(m.context, m.context))
+	}
+
+	m.formContext = m.context
+	if m.allContexts {
+		m.formContext = allContextsChoice
+	}
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().Title("Switch to context").Options(options...).Value(&m.formContext),
+		),
+	)
+	m.mode = modeSwitchContext
+	return m, m.form.Init()
+}
+
+// contextsInView lists the contexts whose tasks are on screen.
+func (m *model) contextsInView() []string {
+	if !m.allContexts {
+		return []string{m.context}
+	}
+	var contexts []string
+	seen := map[string]bool{}
+	for _, t := range m.tasks {
+		if !seen[t.Context] {
+			seen[t.Context] = true
+			contexts = append(contexts, t.Context)
+		}
+	}
+	return contexts
+}
+
 // --- Form modes ---
 
 func (m *model) enterAddMode() (tea.Model, tea.Cmd) {
 	m.formDesc = ""
-	m.formPriority = PriorityB
+	m.formPriority = config.DefaultPriority
 	m.formEstimate = ""
+	m.formDue = ""
+	m.formNotes = ""
 	m.form = huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().Title("What do you need to do?").Value(&m.formDesc).Validate(notEmpty("Description")),
 			huh.NewSelect[Priority]().Title("Priority?").Options(PriorityOptions()...).Value(&m.formPriority),
 			huh.NewInput().Title("Time estimate? (eg 30m, 2h, 1d)").Value(&m.formEstimate).Validate(notEmpty("Time estimate")),
+			huh.NewInput().Title("Due date? ("+config.DateFormat+", optional)").Value(&m.formDue).Validate(optionalDate),
+			huh.NewText().Title("Notes? (optional)").Value(&m.formNotes),
 		),
 	)
 	m.mode = modeAdd
@@ -340,21 +813,32 @@ func (m *model) enterAddMode() (tea.Model, tea.Cmd) {
 }
 
 func (m *model) enterEditMode() (tea.Model, tea.Cmd) {
-	if len(m.tasks) == 0 {
+	task, ok := m.selectedTask()
+	if !ok {
 		m.status = "No tasks to edit."
 		return m, nil
 	}
 
-	task := m.visibleTasks()[m.table.Cursor()]
 	m.editTaskID = task.ID
 	m.formDesc = task.Description
 	m.formPriority = task.Priority
 	m.formEstimate = task.TimeEstimate
+	m.editTaskContext = task.Context
+	m.formContext = task.Context
+	m.formNotes = task.Notes
+	m.formDue = ""
+	if task.HasDueDate() {
+		due, _ := time.Parse("2006-01-02", task.DueDate)
+		m.formDue = due.Format(config.dateLayout())
+	}
 	m.form = huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().Title("Description").Value(&m.formDesc).Validate(notEmpty("Description")),
 			huh.NewSelect[Priority]().Title("Priority?").Options(PriorityOptions()...).Value(&m.formPriority),
 			huh.NewInput().Title("Time estimate?").Value(&m.formEstimate).Validate(notEmpty("Time estimate")),
+			huh.NewInput().Title("Due date? ("+config.DateFormat+", blank for none)").Value(&m.formDue).Validate(optionalDate),
+			huh.NewText().Title("Notes").Value(&m.formNotes),
+			huh.NewInput().Title("Context").Value(&m.formContext).Validate(notEmpty("Context")),
 		),
 	)
 	m.mode = modeEdit
@@ -362,12 +846,12 @@ func (m *model) enterEditMode() (tea.Model, tea.Cmd) {
 }
 
 func (m *model) enterDeleteMode() (tea.Model, tea.Cmd) {
-	if len(m.tasks) == 0 {
+	task, ok := m.selectedTask()
+	if !ok {
 		m.status = "No tasks to delete."
 		return m, nil
 	}
 
-	task := m.visibleTasks()[m.table.Cursor()]
 	m.editTaskID = task.ID
 	m.formConfirm = true
 	m.form = confirmForm(fmt.Sprintf("Delete '%s'?", task.Description), &m.formConfirm)
@@ -377,14 +861,18 @@ func (m *model) enterDeleteMode() (tea.Model, tea.Cmd) {
 
 func (m *model) enterCarryMode() (tea.Model, tea.Cmd) {
 	toDate := tomorrow(m.date)
-	candidates, err := m.store.GetCarryOverCandidates(m.date, toDate, m.context)
-	if err != nil {
-		m.status = "Error loading tasks."
-		return m, nil
+	var candidates []Task
+	for _, context := range m.contextsInView() {
+		found, err := m.store.GetCarryOverCandidates(m.date, toDate, context)
+		if err != nil {
+			m.status = "Error loading tasks."
+			return m, nil
+		}
+		candidates = append(candidates, found...)
 	}
 
 	if len(candidates) == 0 {
-		incomplete := filterTasks(m.tasks, func(t Task) bool { return t.Status != StatusDone })
+		incomplete := filterTasks(m.tasks, func(t Task) bool { return !t.Status.IsClosed() })
 		if len(incomplete) == 0 {
 			m.status = "No incomplete tasks to carry over."
 		} else {
@@ -400,19 +888,11 @@ func (m *model) enterCarryMode() (tea.Model, tea.Cmd) {
 	return m, m.form.Init()
 }
 
-func (m *model) enterViewDateMode() (tea.Model, tea.Cmd) {
-	m.formDate = ""
-	m.form = huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().Title("Date (dd/mm/yyyy)").Value(&m.formDate).Validate(notEmpty("Date")),
-		),
-	)
-	m.mode = modeViewDate
-	return m, m.form.Init()
-}
-
 func (m *model) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" {
+		if m.mode == modeInboxSchedule || m.mode == modeInboxDelegate {
+			return m.enterInboxChoice() // back to the choice for the same item
+		}
 		m.mode = modeTable
 		m.status = ""
 		return m, nil
@@ -433,15 +913,30 @@ func (m *model) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *model) handleFormComplete() (tea.Model, tea.Cmd) {
 	switch m.mode {
 	case modeAdd:
-		if err := m.store.AddTask(m.date, m.formDesc, m.formPriority, m.formEstimate, m.context); err != nil {
+		task := Task{
+			Date:         m.date,
+			Description:  m.formDesc,
+			Priority:     m.formPriority,
+			TimeEstimate: m.formEstimate,
+			DueDate:      formDueDate(m.formDue),
+			Notes:        strings.TrimSpace(m.formNotes),
+			Context:      m.context,
+		}
+		if _, err := m.store.CreateTask(task); err != nil {
 			m.status = "Error adding task."
 		} else {
 			m.status = "Task added."
 		}
 
 	case modeEdit:
-		if err := m.store.UpdateTask(m.editTaskID, m.formDesc, m.formPriority, m.formEstimate); err != nil {
+		if err := m.store.UpdateTask(m.editTaskID, m.formDesc, m.formPriority, m.formEstimate, formDueDate(m.formDue), strings.TrimSpace(m.formNotes)); err != nil {
 			m.status = "Error updating task."
+		} else if context := strings.TrimSpace(m.formContext); context != m.editTaskContext {
+			if err := m.store.MoveTask(m.editTaskID, context); err != nil {
+				m.status = "Error moving task."
+			} else {
+				m.status = fmt.Sprintf("Task moved to %s.", context)
+			}
 		} else {
 			m.status = "Task updated."
 		}
@@ -458,21 +953,32 @@ func (m *model) handleFormComplete() (tea.Model, tea.Cmd) {
 	case modeConfirmCarry:
 		if m.formConfirm {
 			toDate := tomorrow(m.date)
-			if err := m.store.CarryOverTasks(m.carryCandidates, toDate, m.context); err != nil {
-				m.status = "Error carrying over tasks."
-			} else {
-				m.status = "Tasks carried over."
+			m.status = "Tasks carried over."
+			for _, context := range m.contextsInView() {
+				tasks := filterTasks(m.carryCandidates, func(t Task) bool { return t.Context == context })
+				if err := m.store.CarryOverTasks(tasks, toDate, context); err != nil {
+					m.status = "Error carrying over tasks."
+					break
+				}
 			}
 		}
 
-	case modeViewDate:
-		t, err := time.Parse("02/01/2006", m.formDate)
-		if err != nil {
-			m.status = "Invalid date. Use dd/mm/yyyy."
+	case modeSwitchContext:
+		if m.formContext == allContextsChoice {
+			m.allContexts = true
 		} else {
-			m.date = t.Format("2006-01-02")
-			m.status = ""
+			m.allContexts = false
+			m.context = m.formContext
 		}
+		m.status = ""
+		m.clearFilter()
+		m.mode = modeTable
+		m.refreshTasks()
+		m.inboxSkipped = nil
+		return m.processInbox(false)
+
+	case modeInbox, modeInboxSchedule, modeInboxDelegate:
+		return m.handleInboxForm()
 	}
 
 	m.mode = modeTable
@@ -483,6 +989,10 @@ func (m *model) handleFormComplete() (tea.Model, tea.Cmd) {
 // --- Helpers ---
 
 func (m *model) importTasks() (tea.Model, tea.Cmd) {
+	if m.allContexts {
+		m.status = "Switch to a single context to import."
+		return m, nil
+	}
 	if m.latestDateWithTasks == "" {
 		return m, nil
 	}
@@ -491,7 +1001,7 @@ func (m *model) importTasks() (tea.Model, tea.Cmd) {
 		m.status = "Error importing tasks."
 	} else {
 		lt, _ := time.Parse("2006-01-02", m.latestDateWithTasks)
-		m.status = fmt.Sprintf("Tasks imported from %s.", lt.Format("02/01/2006"))
+		m.status = fmt.Sprintf("Tasks imported from %s.", lt.Format(config.dateLayout()))
 	}
 
 	m.refreshTasks()
@@ -499,15 +1009,26 @@ func (m *model) importTasks() (tea.Model, tea.Cmd) {
 }
 
 func (m *model) refreshTasks() {
-	tasks, err := m.store.GetTasksForDate(m.date, m.context)
+	var tasks []Task
+	var err error
+	if m.allContexts {
+		tasks, err = m.store.GetTasksForDateAllContexts(m.date)
+	} else {
+		tasks, err = m.store.GetTasksForDate(m.date, m.context)
+	}
 	if err != nil {
 		m.tasks = nil
 	} else {
 		m.tasks = tasks
 	}
 
+	m.inboxCount = 0
+	if items, err := m.store.GetInboxItems(m.inboxContext(), false); err == nil {
+		m.inboxCount = len(items)
+	}
+
 	m.latestDateWithTasks = ""
-	if len(m.tasks) == 0 {
+	if len(m.tasks) == 0 && !m.allContexts {
 		if date, err := m.store.GetLatestDateWithIncompleteTasks(m.date, m.context); err == nil {
 			m.latestDateWithTasks = date
 		}
@@ -520,7 +1041,8 @@ func (m *model) rebuildTable() {
 	cursor := m.table.Cursor()
 
 	visible := m.visibleTasks()
-	cols := tableColumns(m.width)
+	today := time.Now().Format("2006-01-02")
+	cols := tableColumns(m.width, m.allContexts)
 	rows := make([]table.Row, len(visible))
 	for i, t := range visible {
 		// Show the original 1-based index so number-jump stays consistent
@@ -531,13 +1053,21 @@ func (m *model) rebuildTable() {
 				break
 			}
 		}
-		rows[i] = table.Row{
-			fmt.Sprintf("%d", origIdx),
-			t.DisplayDescription(),
+		row := table.Row{fmt.Sprintf("%d", origIdx)}
+		if m.allContexts {
+			row = append(row, t.Context)
+		}
+		desc := t.DisplayDescription()
+		if m.filteredTasks != nil {
+			desc = m.filterQuery.highlight(t.Description) + strings.TrimPrefix(desc, t.Description)
+		}
+		rows[i] = append(row,
+			desc,
 			string(t.Priority),
 			t.TimeEstimate,
+			t.DueDisplay(today),
 			t.Status.Symbol(),
-		}
+		)
 	}
 
 	height := len(visible) + 2 // +2 for header row + border
@@ -548,6 +1078,22 @@ func (m *model) rebuildTable() {
 		height = maxH
 	}
 
+	t := newTable(cols, rows, height, m.keys.tableKeyMap())
+
+	// Preserve cursor position
+	if cursor >= len(visible) {
+		cursor = len(visible) - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	t.SetCursor(cursor)
+
+	m.table = t
+}
+
+// newTable builds a focused table in the theme's styles.
+func newTable(cols []table.Column, rows []table.Row, height int, km table.KeyMap) table.Model {
 	t := table.New(
 		table.WithColumns(cols),
 		table.WithRows(rows),
@@ -558,29 +1104,20 @@ func (m *model) rebuildTable() {
 	s := table.DefaultStyles()
 	s.Header = s.Header.
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#555")).
+		BorderForeground(theme.Border).
 		BorderBottom(true).
 		Bold(true)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("#fff")).
-		Background(lipgloss.Color("#7c3aed")).
-		Bold(true)
+	s.Selected = theme.selectedStyle(s.Selected)
 	t.SetStyles(s)
-
-	// Preserve cursor position
-	if cursor >= len(visible) {
-		cursor = len(visible) - 1
-	}
-	if cursor < 0 {
-		cursor = 0
-	}
-	t.SetCursor(cursor)
-
-	m.table = t
+	t.KeyMap = km
+	return t
 }
 
-func tableColumns(width int) []table.Column {
-	fixed := 4 + 10 + 8 + 6 + 8 // #, Priority, Time, Status + padding/borders
+func tableColumns(width int, withContext bool) []table.Column {
+	fixed := 4 + 10 + 8 + 10 + 6 + 10 // #, Priority, Time, Due, Status + padding/borders
+	if withContext {
+		fixed += contextColumnWidth + 2
+	}
 	taskWidth := width - fixed
 	if taskWidth < 20 {
 		taskWidth = 20
@@ -588,15 +1125,21 @@ func tableColumns(width int) []table.Column {
 	if taskWidth > 80 {
 		taskWidth = 80
 	}
-	return []table.Column{
-		{Title: "#", Width: 4},
+	cols := []table.Column{{Title: "#", Width: 4}}
+	if withContext {
+		cols = append(cols, table.Column{Title: "Context", Width: contextColumnWidth})
+	}
+	return append(cols, []table.Column{
 		{Title: "Task", Width: taskWidth},
 		{Title: "Priority", Width: 10},
 		{Title: "Time", Width: 8},
+		{Title: "Due", Width: 10},
 		{Title: "Status", Width: 6},
-	}
+	}...)
 }
 
+const contextColumnWidth = 12
+
 func formatHeading(date string) string {
 	t, _ := time.Parse("2006-01-02", date)
 	return t.Format("Monday 2 January 2006")
@@ -621,6 +1164,24 @@ func confirmForm(title string, value *bool) *huh.Form {
 	).WithKeyMap(km)
 }
 
+// optionalDate validates a form field that may be blank or a date in the configured format.
+func optionalDate(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := parseInputDate(s)
+	return err
+}
+
+// formDueDate converts the due-date form field to yyyy-mm-dd, or "" when left blank.
+func formDueDate(s string) string {
+	date, err := parseInputDate(s)
+	if err != nil {
+		return ""
+	}
+	return date
+}
+
 func notEmpty(field string) func(string) error {
 	return func(s string) error {
 		if s == "" {
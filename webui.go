@@ -0,0 +1,117 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+//go:embed web/index.html
+var webFiles embed.FS
+
+// webPage is the single-page UI served at / by "gtd serve". It talks to the JSON API like any
+// other client, so the page itself is served without a token and asks for one when needed.
+var webPage = template.Must(template.ParseFS(webFiles, "web/index.html"))
+
+func (a *apiServer) serveWebUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	webPage.Execute(w, struct{ DefaultContext, Today string }{
+		DefaultContext: config.DefaultContext,
+		Today:          a.store.now().Format("2006-01-02"),
+	})
+}
+
+func serveThemeCSS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, themeCSS())
+}
+
+// themeCSS turns the active theme into CSS custom properties, so the web UI shows priorities in
+// the same colours as the TUI. Adaptive colours get their dark variant under prefers-color-scheme.
+func themeCSS() string {
+	vars := []struct {
+		name  string
+		color lipgloss.TerminalColor
+	}{
+		{"priority-a", PriorityA.Color()},
+		{"priority-b", PriorityB.Color()},
+		{"priority-c", PriorityC.Color()},
+		{"priority-d", PriorityD.Color()},
+		{"accent", theme.Accent},
+		{"success", theme.Success},
+		{"muted", theme.Muted},
+		{"border", theme.Border},
+	}
+
+	var light, dark strings.Builder
+	for _, v := range vars {
+		l, d := cssColors(v.color)
+		fmt.Fprintf(&light, "  --%s: %s;\n", v.name, l)
+		if d != l {
+			fmt.Fprintf(&dark, "    --%s: %s;\n", v.name, d)
+		}
+	}
+	css := ":root {\n" + light.String() + "}\n"
+	if dark.Len() > 0 {
+		css += "@media (prefers-color-scheme: dark) {\n  :root {\n" + dark.String() + "  }\n}\n"
+	}
+	return css
+}
+
+// cssColors gives the CSS for a terminal colour on light and dark backgrounds. No colour, as in
+// the monochrome theme, inherits the text colour.
+func cssColors(c lipgloss.TerminalColor) (light, dark string) {
+	switch c := c.(type) {
+	case lipgloss.Color:
+		css := cssColor(string(c))
+		return css, css
+	case lipgloss.AdaptiveColor:
+		return cssColor(c.Light), cssColor(c.Dark)
+	default:
+		return "currentColor", "currentColor"
+	}
+}
+
+// cssColor converts a lipgloss colour string, either "#rrggbb" or an ANSI colour number, to CSS.
+func cssColor(s string) string {
+	if strings.HasPrefix(s, "#") {
+		return s
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 255 {
+		return "currentColor"
+	}
+	return ansiHex(n)
+}
+
+var ansiBasic = [16]string{
+	"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+	"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// ansiHex gives the usual xterm palette value of a 256-colour ANSI number.
+func ansiHex(n int) string {
+	switch {
+	case n < 16:
+		return ansiBasic[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + 40*v
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		g := 8 + 10*(n-232)
+		return fmt.Sprintf("#%02x%02x%02x", g, g, g)
+	}
+}
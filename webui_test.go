@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func get(t *testing.T, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestWebUI(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DefaultContext = `o'brien "ops"`
+	useConfig(t, cfg)
+	_, srv := newTestAPI(t, "s3cret")
+
+	// The page and its stylesheet need no token; the API behind them still does
+	resp, page := get(t, srv.URL+"/")
+	if resp.StatusCode != 200 || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("GET / = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(page, `const serverToday = "2025-06-02";`) {
+		t.Error("expected today's date in the page")
+	}
+	if !strings.Contains(page, `const defaultContext = "o'brien \"ops\"";`) {
+		t.Errorf("expected the default context escaped as a JS string:\n%s", page)
+	}
+	if resp, css := get(t, srv.URL+"/theme.css"); resp.StatusCode != 200 || !strings.Contains(css, "--priority-a:") {
+		t.Errorf("GET /theme.css = %d\n%s", resp.StatusCode, css)
+	}
+	if resp, _ := get(t, srv.URL+"/tasks"); resp.StatusCode != 401 {
+		t.Errorf("GET /tasks without a token = %d, want 401", resp.StatusCode)
+	}
+	if resp, _ := get(t, srv.URL+"/index.html"); resp.StatusCode != 401 {
+		t.Errorf("only / itself should be open, got %d for /index.html", resp.StatusCode)
+	}
+}
+
+func TestThemeCSS(t *testing.T) {
+	defer applyTheme(theme)
+
+	applyTheme(themes["default"])
+	css := themeCSS()
+	if !strings.Contains(css, "--priority-a: "+string(theme.PriorityA.(lipgloss.Color))+";") {
+		t.Errorf("expected the theme's priority A colour:\n%s", css)
+	}
+
+	applyTheme(themes["monochrome"])
+	if css := themeCSS(); !strings.Contains(css, "--border: currentColor;") || strings.Contains(css, "@media") {
+		t.Errorf("expected plain inherited colours for monochrome:\n%s", css)
+	}
+
+	applyTheme(Theme{PriorityA: lipgloss.AdaptiveColor{Light: "#aa0000", Dark: "196"}, PriorityB: lipgloss.Color("244")})
+	css = themeCSS()
+	if !strings.Contains(css, ":root {\n  --priority-a: #aa0000;\n  --priority-b: #808080;") ||
+		!strings.Contains(css, "@media (prefers-color-scheme: dark) {\n  :root {\n    --priority-a: #ff0000;\n  }\n}") {
+		t.Errorf("unexpected CSS for an adaptive theme:\n%s", css)
+	}
+}
+
+func TestAnsiHex(t *testing.T) {
+	for n, want := range map[int]string{0: "#000000", 9: "#ff0000", 16: "#000000", 21: "#0000ff", 196: "#ff0000", 110: "#87afd7", 232: "#080808", 244: "#808080", 255: "#eeeeee"} {
+		if got := ansiHex(n); got != want {
+			t.Errorf("ansiHex(%d) = %s, want %s", n, got, want)
+		}
+	}
+	if got := cssColor("not a colour"); got != "currentColor" {
+		t.Errorf("cssColor of junk = %s", got)
+	}
+}